@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"io"
+	"os"
+)
+
+/*
+	APNGWriter writes an animated PNG one frame at a time. Each frame is encoded as a
+	PNG of its own; the header of the first is kept, followed by the animation control
+	chunk, and the image data of every frame is copied after a frame control chunk, as
+	IDAT for the first frame and fdAT for the others. The number of frames is only known
+	at the end, so Close writes it back into the animation control chunk.
+*/
+
+type APNGWriter struct {
+	file      *os.File
+	w         *bufio.Writer
+	delay     int
+	loopCount int
+	comment   string
+	header    []byte // IHDR of the first frame, which every frame must share
+	sequence  uint32 // number of the next fcTL or fdAT chunk
+	frames    int
+}
+
+// pngSignature starts every PNG file
+const pngSignature = "\x89PNG\r\n\x1a\n"
+
+// acTLOffset is where the animation control chunk starts: after the signature and the 25 bytes of IHDR, where any PNG's second chunk starts
+const acTLOffset = len(pngSignature) + 12 + 13
+
+// NewAPNGWriter creates filename.png, made as opts says
+func NewAPNGWriter(filename string, opts FrameOptions) (*APNGWriter, error) {
+	file, err := os.Create(filename + ".png")
+	if err != nil {
+		return nil, err
+	}
+	return &APNGWriter{file: file, w: bufio.NewWriter(file), delay: opts.Delay, loopCount: opts.LoopCount, comment: opts.Comment}, nil
+}
+
+// AddFrame appends img to the animation
+func (a *APNGWriter) AddFrame(img image.Image) error {
+	var one bytes.Buffer
+	if err := png.Encode(&one, img); err != nil {
+		return err
+	}
+	chunks, err := pngChunks(one.Bytes())
+	if err != nil {
+		return err
+	}
+
+	if a.frames == 0 {
+		a.header = chunks[0].data
+		a.w.WriteString(pngSignature)
+		a.chunk("IHDR", a.header)
+		a.chunk("acTL", a.animationControl(0))
+		if a.comment != "" {
+			a.chunk("tEXt", pngText("Comment", a.comment))
+		}
+	} else if !bytes.Equal(chunks[0].data, a.header) {
+		return fmt.Errorf("apng: frame %d is not the size and colour type of the first", a.frames)
+	}
+
+	b := img.Bounds()
+	control := make([]byte, 26)
+	binary.BigEndian.PutUint32(control[0:], a.sequence)
+	binary.BigEndian.PutUint32(control[4:], uint32(b.Dx()))
+	binary.BigEndian.PutUint32(control[8:], uint32(b.Dy()))
+	binary.BigEndian.PutUint16(control[20:], uint16(a.delay))
+	binary.BigEndian.PutUint16(control[22:], 100) // the delay is in hundredths of a second
+	a.chunk("fcTL", control)
+	a.sequence++
+
+	for _, c := range chunks {
+		if c.kind != "IDAT" {
+			continue
+		}
+		if a.frames == 0 {
+			a.chunk("IDAT", c.data)
+			continue
+		}
+		data := make([]byte, 4+len(c.data))
+		binary.BigEndian.PutUint32(data, a.sequence)
+		copy(data[4:], c.data)
+		a.chunk("fdAT", data)
+		a.sequence++
+	}
+	a.frames++
+	return nil
+}
+
+// Close ends the animation, writes its number of frames and closes its file
+func (a *APNGWriter) Close() error {
+	if a.frames == 0 {
+		a.file.Close()
+		return fmt.Errorf("apng: no frames to write")
+	}
+	a.chunk("IEND", nil)
+	if err := a.w.Flush(); err != nil {
+		a.file.Close()
+		return err
+	}
+	var patched bytes.Buffer
+	writePNGChunk(&patched, "acTL", a.animationControl(a.frames))
+	if _, err := a.file.WriteAt(patched.Bytes(), int64(acTLOffset)); err != nil {
+		a.file.Close()
+		return err
+	}
+	return a.file.Close()
+}
+
+// animationControl returns the data of the acTL chunk for the given number of frames
+func (a *APNGWriter) animationControl(frames int) []byte {
+	plays := 0 // for ever
+	if a.loopCount > 0 {
+		plays = a.loopCount + 1
+	}
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint32(data[0:], uint32(frames))
+	binary.BigEndian.PutUint32(data[4:], uint32(plays))
+	return data
+}
+
+func (a *APNGWriter) chunk(kind string, data []byte) {
+	writePNGChunk(a.w, kind, data)
+}
+
+// pngChunk is a chunk of a PNG file
+type pngChunk struct {
+	kind string
+	data []byte
+}
+
+// pngChunks splits an encoded PNG into its chunks, the first of which is IHDR
+func pngChunks(encoded []byte) ([]pngChunk, error) {
+	if !bytes.HasPrefix(encoded, []byte(pngSignature)) {
+		return nil, fmt.Errorf("apng: frame is not a PNG")
+	}
+	var chunks []pngChunk
+	for rest := encoded[len(pngSignature):]; len(rest) >= 12; {
+		n := int(binary.BigEndian.Uint32(rest))
+		if 12+n > len(rest) {
+			return nil, fmt.Errorf("apng: frame is cut short")
+		}
+		chunks = append(chunks, pngChunk{kind: string(rest[4:8]), data: rest[8 : 8+n]})
+		rest = rest[12+n:]
+	}
+	if len(chunks) == 0 || chunks[0].kind != "IHDR" {
+		return nil, fmt.Errorf("apng: frame does not start with IHDR")
+	}
+	return chunks, nil
+}
+
+// pngText returns the data of a tEXt chunk holding text under keyword
+func pngText(keyword, text string) []byte {
+	return []byte(keyword + "\x00" + text)
+}
+
+// writePNGChunk writes a chunk of kind with its length and CRC
+func writePNGChunk(w io.Writer, kind string, data []byte) {
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(data)))
+	w.Write(length[:])
+	crc := crc32.NewIEEE()
+	crc.Write([]byte(kind))
+	crc.Write(data)
+	w.Write([]byte(kind))
+	w.Write(data)
+	var sum [4]byte
+	binary.BigEndian.PutUint32(sum[:], crc.Sum32())
+	w.Write(sum[:])
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"os"
+)
+
+/*
+	AVIWriter writes a Motion JPEG video in an AVI file one frame at a time, each frame
+	a JPEG image of its own, which any video editor can read. The headers are written
+	with the first frame, when its size is known; the number of frames, the sizes of
+	the lists and the index of the frames are only known at the end, so Close writes
+	the index after the frames and the counts back into the headers.
+
+	The file is laid out as
+
+		RIFF 'AVI '
+			LIST 'hdrl'
+				avih              main header: frame rate, size, number of frames
+				LIST 'strl'
+					strh          stream header: 'vids' of 'MJPG'
+					strf          BITMAPINFOHEADER
+			LIST 'INFO'           only with a comment
+				ICMT              the comment
+			LIST 'movi'
+				00dc ...          one JPEG per frame
+			idx1                  offset and size of each frame
+*/
+
+type AVIWriter struct {
+	file    *os.File
+	w       *bufio.Writer
+	delay   int
+	comment string
+	info    int // bytes of the INFO list, which moves the movi list along
+	index   []aviIndex
+	movi    int // bytes of frame chunks written into the movi list
+	largest int // largest frame chunk
+}
+
+// aviIndex is the entry of a frame in idx1
+type aviIndex struct {
+	offset, size int
+}
+
+// where the fields Close fills in are in the file, from the layout of the headers
+const (
+	aviRIFFSize    = 4
+	aviTotalFrames = 32 + 16
+	aviAVIHBuffer  = 32 + 28
+	aviLength      = 108 + 32
+	aviSTRHBuffer  = 108 + 36
+	aviMoviSize    = 216 // without an INFO list
+	aviMoviStart   = 220 // the 'movi' fourcc, from which idx1 offsets count, without an INFO list
+	aviQuality     = 90  // JPEG quality of the frames
+)
+
+// NewAVIWriter creates filename.avi, showing every frame for opts.Delay hundredths of a second
+func NewAVIWriter(filename string, opts FrameOptions) (*AVIWriter, error) {
+	file, err := os.Create(filename + ".avi")
+	if err != nil {
+		return nil, err
+	}
+	delay := opts.Delay
+	if delay < 1 {
+		delay = 1 // a video needs a frame rate
+	}
+	return &AVIWriter{file: file, w: bufio.NewWriter(file), delay: delay, comment: opts.Comment}, nil
+}
+
+// AddFrame encodes img as JPEG and appends it to the video
+func (v *AVIWriter) AddFrame(img image.Image) error {
+	var frame bytes.Buffer
+	if err := jpeg.Encode(&frame, img, &jpeg.Options{Quality: aviQuality}); err != nil {
+		return err
+	}
+	if len(v.index) == 0 {
+		v.writeHeaders(img.Bounds().Dx(), img.Bounds().Dy())
+	}
+
+	v.index = append(v.index, aviIndex{offset: 4 + v.movi, size: frame.Len()})
+	v.w.WriteString("00dc")
+	v.u32(frame.Len())
+	v.w.Write(frame.Bytes())
+	size := 8 + frame.Len()
+	if frame.Len()%2 == 1 {
+		v.w.WriteByte(0) // chunks are padded to an even size
+		size++
+	}
+	v.movi += size
+	if frame.Len() > v.largest {
+		v.largest = frame.Len()
+	}
+	return nil
+}
+
+// writeHeaders writes everything before the first frame for frames of width by height
+func (v *AVIWriter) writeHeaders(width, height int) {
+	v.w.WriteString("RIFF")
+	v.u32(0) // filled in by Close
+	v.w.WriteString("AVI ")
+
+	v.w.WriteString("LIST")
+	v.u32(192)
+	v.w.WriteString("hdrl")
+
+	v.w.WriteString("avih")
+	v.u32(56)
+	v.u32(v.delay * 10000) // microseconds per frame
+	v.u32(0)               // max bytes per second
+	v.u32(0)               // padding granularity
+	v.u32(0x10)            // AVIF_HASINDEX
+	v.u32(0)               // total frames, filled in by Close
+	v.u32(0)               // initial frames
+	v.u32(1)               // streams
+	v.u32(0)               // suggested buffer size, filled in by Close
+	v.u32(width)
+	v.u32(height)
+	v.w.Write(make([]byte, 16))
+
+	v.w.WriteString("LIST")
+	v.u32(116)
+	v.w.WriteString("strl")
+
+	v.w.WriteString("strh")
+	v.u32(56)
+	v.w.WriteString("vids")
+	v.w.WriteString("MJPG")
+	v.u32(0)       // flags
+	v.u32(0)       // priority and language
+	v.u32(0)       // initial frames
+	v.u32(v.delay) // scale: the frame rate is rate/scale
+	v.u32(100)     // rate
+	v.u32(0)       // start
+	v.u32(0)       // length, filled in by Close
+	v.u32(0)       // suggested buffer size, filled in by Close
+	v.u32(0xffffffff)
+	v.u32(0) // sample size
+	v.u16(0)
+	v.u16(0)
+	v.u16(width)
+	v.u16(height)
+
+	v.w.WriteString("strf")
+	v.u32(40)
+	v.u32(40)
+	v.u32(width)
+	v.u32(height)
+	v.u16(1)  // planes
+	v.u16(24) // bits per pixel
+	v.w.WriteString("MJPG")
+	v.u32(width * height * 3)
+	v.w.Write(make([]byte, 16))
+
+	if v.comment != "" {
+		text := len(v.comment) + 1 // ended by a zero byte
+		padded := text + text%2
+		v.info = 20 + padded
+		v.w.WriteString("LIST")
+		v.u32(12 + padded)
+		v.w.WriteString("INFO")
+		v.w.WriteString("ICMT")
+		v.u32(text)
+		v.w.WriteString(v.comment)
+		v.w.Write(make([]byte, padded-len(v.comment)))
+	}
+
+	v.w.WriteString("LIST")
+	v.u32(0) // filled in by Close
+	v.w.WriteString("movi")
+}
+
+// Close writes the index of the frames and the counts left out of the headers, and closes the file
+func (v *AVIWriter) Close() error {
+	if len(v.index) == 0 {
+		v.file.Close()
+		return fmt.Errorf("avi: no frames to write")
+	}
+	v.w.WriteString("idx1")
+	v.u32(16 * len(v.index))
+	for _, entry := range v.index {
+		v.w.WriteString("00dc")
+		v.u32(0x10) // AVIIF_KEYFRAME
+		v.u32(entry.offset)
+		v.u32(entry.size)
+	}
+	if err := v.w.Flush(); err != nil {
+		v.file.Close()
+		return err
+	}
+
+	end := v.info + aviMoviStart + 4 + v.movi + 8 + 16*len(v.index)
+	for _, field := range []struct{ at, value int }{
+		{aviRIFFSize, end - 8},
+		{aviTotalFrames, len(v.index)},
+		{aviAVIHBuffer, v.largest + 8},
+		{aviLength, len(v.index)},
+		{aviSTRHBuffer, v.largest + 8},
+		{v.info + aviMoviSize, 4 + v.movi},
+	} {
+		var b [4]byte
+		binary.LittleEndian.PutUint32(b[:], uint32(field.value))
+		if _, err := v.file.WriteAt(b[:], int64(field.at)); err != nil {
+			v.file.Close()
+			return err
+		}
+	}
+	return v.file.Close()
+}
+
+func (v *AVIWriter) u32(x int) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], uint32(x))
+	v.w.Write(b[:])
+}
+
+func (v *AVIWriter) u16(x int) {
+	var b [2]byte
+	binary.LittleEndian.PutUint16(b[:], uint16(x))
+	v.w.Write(b[:])
+}
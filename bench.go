@@ -0,0 +1,142 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"cgsimu/engine"
+)
+
+/*
+	RunBench times one generation of the one cluster model on large random boards with
+	each kind of spatial index, and checks that every index gives exactly the board the
+	brute force search gives. It then times the grid shared by --workers goroutines and
+	checks that two workers give the same board. Last it times the Voronoi strategy on
+	random boards of --voronoi sites, and checks that their cells cover the board. It
+	returns the exit status like RunCLI.
+*/
+
+func RunBench(args []string) int {
+	flags := flag.NewFlagSet("cgsimu bench", flag.ContinueOnError)
+	sizes := flags.String("sizes", "1000,10000,20000", "comma separated numbers of cells to benchmark")
+	seed := flags.Int64("seed", 1, "seed of the random boards")
+	workers := flags.Int("workers", runtime.NumCPU(), "goroutines for the parallel run")
+	voronoi := flags.String("voronoi", "1000,10000,50000", "comma separated numbers of sites to time the Voronoi strategy on, empty for none")
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+
+	counts, err := benchSizes("sizes", *sizes)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	sites, err := benchSizes("voronoi", *voronoi)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	p := engine.DefaultConfig().Params()
+	fmt.Printf("%8s %8s %14s %8s\n", "cells", "index", "time", "speedup")
+	for _, n := range counts {
+		var reference engine.GameBoard
+		var brute time.Duration
+		// brute goes first so that every other index is compared with it
+		for _, kind := range []string{"brute", "grid"} {
+			p.Index = kind
+			board := benchBoard(n, *seed)
+			start := time.Now()
+			next := board.UpdateOneBoard(p)
+			elapsed := time.Since(start)
+
+			speedup := "-"
+			if kind == "brute" {
+				reference, brute = next, elapsed
+			} else {
+				speedup = fmt.Sprintf("%.1fx", brute.Seconds()/elapsed.Seconds())
+				if !reflect.DeepEqual(next.Cells(), reference.Cells()) {
+					fmt.Fprintf(os.Stderr, "Error: %s index gives a different board than brute force with %d cells\n", kind, n)
+					return 1
+				}
+			}
+			fmt.Printf("%8d %8s %14v %8s\n", n, kind, elapsed, speedup)
+		}
+
+		if *workers > 1 {
+			p.Index = "grid"
+			p.Workers = 2
+			pair := benchBoard(n, *seed).UpdateOneBoard(p)
+			p.Workers = *workers
+			board := benchBoard(n, *seed)
+			start := time.Now()
+			next := board.UpdateOneBoard(p)
+			elapsed := time.Since(start)
+			if !reflect.DeepEqual(next.Cells(), pair.Cells()) {
+				fmt.Fprintf(os.Stderr, "Error: %d workers give a different board than 2 workers with %d cells\n", *workers, n)
+				return 1
+			}
+			fmt.Printf("%8d %8s %14v %8s\n", n, fmt.Sprintf("grid x%d", *workers), elapsed, fmt.Sprintf("%.1fx", brute.Seconds()/elapsed.Seconds()))
+			p.Workers = 1
+		}
+	}
+
+	if len(sites) > 0 {
+		fmt.Printf("\n%8s %14s %14s\n", "sites", "voronoi", "per site")
+	}
+	for _, n := range sites {
+		board := benchBoard(n, *seed)
+		start := time.Now()
+		next := engine.Voronoi(&board)
+		elapsed := time.Since(start)
+
+		total := 0.0
+		for _, c := range next.Cells() {
+			total += engine.GetArea(c)
+		}
+		if area := next.Width() * next.Width(); math.Abs(total-area) > 1e-6*area {
+			fmt.Fprintf(os.Stderr, "Error: the Voronoi cells of %d sites cover %g of a board of %g\n", n, total, area)
+			return 1
+		}
+		fmt.Printf("%8d %14v %14v\n", n, elapsed, elapsed/time.Duration(n))
+	}
+	return 0
+}
+
+// benchSizes reads the comma separated numbers of cells given to flag name, none if value is empty
+func benchSizes(name, value string) ([]int, error) {
+	var counts []int
+	if strings.TrimSpace(value) == "" {
+		return counts, nil
+	}
+	for _, field := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("--%s: %q is not a number of cells", name, field)
+		}
+		counts = append(counts, n)
+	}
+	return counts, nil
+}
+
+// benchBoard scatters numCells cells uniformly over a board sized to keep about 20 cells in each search radius
+func benchBoard(numCells int, seed int64) engine.GameBoard {
+	rng := rand.New(rand.NewSource(seed))
+	width := math.Sqrt(float64(numCells)) * 6
+	cells := make([]engine.Cell, numCells)
+	for i := range cells {
+		cells[i] = engine.NewCell(rng.Float64()*width, rng.Float64()*width)
+	}
+	return engine.NewGameBoard(cells, width, rng)
+}
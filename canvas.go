@@ -58,6 +58,11 @@ func (c *Canvas) ArcTo(x, y, radiusX, radiusY, degStart, degEnd float64) {
 	c.gc.ArcTo(x, y, radiusX, radiusY, degStart, degEnd)
 }
 
+// Scale everything drawn from now on by sx across and sy down, but for ClearRect
+func (c *Canvas) Scale(sx, sy float64) {
+	c.gc.Scale(sx, sy)
+}
+
 // Set the line color
 func (c *Canvas) SetStrokeColor(col color.Color) {
 	c.gc.SetStrokeColor(col)
@@ -117,24 +122,29 @@ func (c *Canvas) Ellipse(cx, cy, rx, ry float64) {
 
 // Save the current canvas to a PNG file
 func (c *Canvas) SaveToPNG(filename string) {
-	f, err := os.Create(filename)
-	if err != nil {
+	if err := writePNG(filename, c.img); err != nil {
 		log.Println(err)
 		os.Exit(1)
 	}
-	defer f.Close()
-	b := bufio.NewWriter(f)
-	err = png.Encode(b, c.img)
+	fmt.Printf("Wrote %s OK.\n", filename)
+}
+
+// writePNG saves img to the PNG file filename, and returns any error met doing so
+func writePNG(filename string, img image.Image) error {
+	f, err := os.Create(filename)
 	if err != nil {
-		log.Println(err)
-		os.Exit(1)
+		return err
 	}
-	err = b.Flush()
-	if err != nil {
-		log.Println(err)
-		os.Exit(1)
+	b := bufio.NewWriter(f)
+	if err := png.Encode(b, img); err != nil {
+		f.Close()
+		return err
 	}
-	fmt.Printf("Wrote %s OK.\n", filename)
+	if err := b.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
 }
 
 // Return the width of the canvas
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"cgsimu/engine"
+)
+
+const usage = `Usage:
+  cgsimu run onecluster [flags]   simulate one cluster of cells
+  cgsimu run twocluster [flags]   simulate the source and sink model
+  cgsimu autogen [flags]          generate random inputs and simulate one cluster
+  cgsimu resume FILE [flags]      carry on a run from a checkpoint file
+  cgsimu bench [flags]            time the spatial indexes on large boards
+  cgsimu help                     show this message
+
+Run "cgsimu run onecluster --help" (or twocluster, autogen) for the flags of
+each command. Every key of the input files can be overridden by a flag of the
+same name, e.g. --searchRadius 20 or --strategy voronoi.
+`
+
+// twoClusterSkips are the config keys the source and sink model does not use
+var twoClusterSkips = map[string]bool{"numZones": true, "addmaze": true}
+
+// resumeKeys are the only config keys that can be changed when resuming, since the others would change the boards
+var resumeKeys = map[string]bool{"numGens": true, "checkpointEvery": true}
+
+// runOptions are the flags shared by every command that runs a simulation
+type runOptions struct {
+	config string
+	out    string
+	name   string
+	inputs string
+
+	outputs Outputs
+
+	flags     *flag.FlagSet
+	overrides map[string]*string
+}
+
+/*
+	RunCLI parses the command line arguments (without the program name), runs the
+	chosen command, and returns the exit status: 0 on success, 1 if the simulation
+	could not run, and 2 for usage errors.
+*/
+
+func RunCLI(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		fmt.Print(usage)
+		return 0
+	case "run":
+		if len(args) < 2 {
+			fmt.Fprintln(os.Stderr, "Error: run needs a model, onecluster or twocluster")
+			fmt.Fprint(os.Stderr, usage)
+			return 2
+		}
+		switch args[1] {
+		case "onecluster":
+			opts := newRunOptions("run onecluster", "OneClusterInputs.txt", "OneCluster", nil)
+			if status, ok := opts.parse(args[2:]); !ok {
+				return status
+			}
+			cfg := opts.loadConfig()
+			MustValidate(cfg.Validate())
+			RunOneCluster(cfg, opts.output(), opts.outputs)
+			return 0
+		case "twocluster":
+			opts := newRunOptions("run twocluster", "TwoClusterInputs.txt", "TwoClusterSS", twoClusterSkips)
+			if status, ok := opts.parse(args[2:]); !ok {
+				return status
+			}
+			cfg := opts.loadConfig()
+			MustValidate(cfg.ValidateTwoCluster())
+			RunTwoCluster(cfg, opts.output(), opts.outputs)
+			return 0
+		}
+		fmt.Fprintf(os.Stderr, "Error: unknown model %q, expected onecluster or twocluster\n", args[1])
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	case "autogen":
+		opts := newRunOptions("autogen", "", "AutoGenerate", nil)
+		opts.flags.StringVar(&opts.inputs, "inputs", "input.txt", "file the randomly generated inputs are written to")
+		if status, ok := opts.parse(args[1:]); !ok {
+			return status
+		}
+		seeded := engine.DefaultConfig()
+		opts.applyOverrides(&seeded)
+		seeded.ResolveSeed()
+		AutoGenerator(opts.inputs, rand.New(rand.NewSource(seeded.Seed)), seeded.Seed)
+		opts.config = opts.inputs
+		cfg := opts.loadConfig()
+		MustValidate(cfg.Validate())
+		RunOneCluster(cfg, opts.output(), opts.outputs)
+		return 0
+	case "resume":
+		opts := newResumeOptions()
+		if len(args) < 2 || strings.HasPrefix(args[1], "-") {
+			if status, ok := opts.parse(args[1:]); !ok {
+				return status
+			}
+			fmt.Fprintln(os.Stderr, "Error: resume needs a checkpoint file")
+			fmt.Fprint(os.Stderr, usage)
+			return 2
+		}
+		if status, ok := opts.parse(args[2:]); !ok {
+			return status
+		}
+		checkpoint, err := engine.LoadCheckpoint(args[1])
+		MustValidate(err)
+		cfg := checkpoint.Config
+		opts.applyOverrides(&cfg)
+		if checkpoint.Model == engine.ModelTwoCluster {
+			MustValidate(cfg.ValidateTwoCluster())
+		} else {
+			MustValidate(cfg.Validate())
+		}
+		if cfg.NumGens < checkpoint.Generation {
+			MustValidate(fmt.Errorf("numGens is %d, but the checkpoint is already at generation %d", cfg.NumGens, checkpoint.Generation))
+		}
+		if opts.name == "" {
+			opts.name = resumedName(args[1], checkpoint.Model)
+		}
+		Resume(checkpoint, cfg, opts.output(), opts.outputs)
+		return 0
+	case "bench":
+		return RunBench(args[1:])
+	}
+
+	fmt.Fprintf(os.Stderr, "Error: unknown command %q\n", args[0])
+	fmt.Fprint(os.Stderr, usage)
+	return 2
+}
+
+/*
+	resumedName returns the default name of the outputs of a run resumed from the
+	checkpoint file filename: the name of the run it was saved by, with "_resumed"
+	added so that the outputs of that run are not overwritten, e.g. OneCluster_resumed
+	for OneCluster.checkpoint.json.
+*/
+
+func resumedName(filename, model string) string {
+	name := filepath.Base(filename)
+	if !strings.HasSuffix(name, ".checkpoint.json") {
+		name = map[string]string{engine.ModelOneCluster: "OneCluster", engine.ModelTwoCluster: "TwoClusterSS"}[model]
+	}
+	return strings.TrimSuffix(name, ".checkpoint.json") + "_resumed"
+}
+
+// newResumeOptions builds the flag set of resume, which has a flag for only the resumeKeys of the config
+func newResumeOptions() *runOptions {
+	skip := make(map[string]bool)
+	for _, key := range engine.ConfigKeys {
+		skip[key.Name] = !resumeKeys[key.Name]
+	}
+	return newRunOptions("resume", "", "", skip)
+}
+
+/*
+	newRunOptions builds the flag set of a command: --config (unless config is empty,
+	when the command makes its own inputs), --out, --name and one flag per config key
+	that is not in skip.
+*/
+
+func newRunOptions(command, config, name string, skip map[string]bool) *runOptions {
+	opts := &runOptions{overrides: make(map[string]*string)}
+	opts.flags = flag.NewFlagSet("cgsimu "+command, flag.ContinueOnError)
+	opts.flags.SetOutput(io.Discard)
+
+	if config != "" {
+		opts.flags.StringVar(&opts.config, "config", config, "input file to read the parameters from (.txt, .yaml or .json)")
+	}
+	opts.flags.StringVar(&opts.out, "out", ".", "directory the output is written to")
+	opts.flags.StringVar(&opts.name, "name", name, "name of the outputs, without their extensions")
+	opts.flags.BoolVar(&opts.outputs.Metrics, "metrics", false, "also write one row of metrics per generation to NAME.metrics.csv")
+	opts.flags.StringVar(&opts.outputs.Trajectory, "trajectory", "", "also write every cell of every generation to NAME.trajectory.csv (csv) or NAME.trajectory.bin (binary)")
+	opts.flags.StringVar(&opts.outputs.Lineage, "lineage", "", "also write the lineage tree of every cell to NAME.lineage.nwk (newick) or NAME.lineage.json (json)")
+	opts.flags.StringVar(&opts.outputs.Graph, "graph", "", "also write the neighbour graph of every generation to NAME.graph.graphml (graphml) or NAME.graph.csv (edgelist), and its degree distribution to NAME.degrees.csv")
+	opts.flags.StringVar(&opts.outputs.Render.Voronoi, "voronoiOverlay", "", "draw the Voronoi cell of every cell under it: outline, or filled by area or density")
+	opts.flags.StringVar(&opts.outputs.Render.Color, "color", "", "colour the cells by density, age, lineage, signalLevel or celltype")
+	opts.flags.StringVar(&opts.outputs.Render.ColorMap, "colorMap", "", "colour map of --color and --voronoiOverlay: viridis, magma, diverging or categorical")
+	opts.flags.Float64Var(&opts.outputs.Render.Radius, "cellRadius", 1, "radius the cells are drawn with")
+	opts.flags.BoolVar(&opts.outputs.Render.Legend, "legend", false, "draw the colour bar, or the key of the colours, of --color")
+	opts.flags.Float64Var(&opts.outputs.Render.Scale, "scale", 1, "size of the frames relative to the board, e.g. 2 to draw a board 500 wide at 1000 pixels")
+	frames := DefaultFrameOptions()
+	opts.flags.StringVar(&opts.outputs.Frames.Format, "format", frames.Format, "format of the frames: gif (NAME.gif), png (NAME_00000.png, ...), apng (NAME.png) or avi (NAME.avi, Motion JPEG)")
+	opts.flags.IntVar(&opts.outputs.Frames.Stride, "stride", 0, "generations between frames, 0 for every third in onecluster and every second in twocluster")
+	opts.flags.IntVar(&opts.outputs.Frames.Delay, "delay", frames.Delay, "hundredths of a second each frame is shown for")
+	opts.flags.IntVar(&opts.outputs.Frames.LoopCount, "loopCount", frames.LoopCount, "times a gif or apng repeats, 0 for ever")
+	opts.flags.StringVar(&opts.outputs.Frames.Palette, "palette", frames.Palette, "colours of a gif: frame (256 of its own per frame) or global (one fixed palette, no flicker)")
+	opts.flags.BoolVar(&opts.outputs.Render.HideZones, "hideZones", false, "do not draw the zones")
+	opts.flags.BoolVar(&opts.outputs.Render.HideMaze, "hideMaze", false, "do not draw the walls of the maze")
+	for _, key := range engine.ConfigKeys {
+		if !skip[key.Name] {
+			opts.overrides[key.Name] = opts.flags.String(key.Name, "", key.Usage)
+		}
+	}
+	return opts
+}
+
+// parse parses args into opts, and returns false with an exit status if the command should stop
+func (opts *runOptions) parse(args []string) (int, bool) {
+	err := opts.flags.Parse(args)
+	if err == flag.ErrHelp {
+		opts.flags.SetOutput(os.Stdout)
+		fmt.Printf("Usage of %s:\n", opts.flags.Name())
+		opts.flags.PrintDefaults()
+		return 0, false
+	}
+	if err == nil && opts.flags.NArg() > 0 {
+		err = fmt.Errorf("unexpected argument %q", opts.flags.Arg(0))
+	}
+	if err == nil && opts.outputs.Trajectory != "" && !contains(engine.TrajectoryFormats, opts.outputs.Trajectory) {
+		err = fmt.Errorf("--trajectory must be one of %s, not %q", strings.Join(engine.TrajectoryFormats, ", "), opts.outputs.Trajectory)
+	}
+	if err == nil && opts.outputs.Lineage != "" && !contains(engine.LineageFormats, opts.outputs.Lineage) {
+		err = fmt.Errorf("--lineage must be one of %s, not %q", strings.Join(engine.LineageFormats, ", "), opts.outputs.Lineage)
+	}
+	if err == nil && opts.outputs.Graph != "" && !contains(engine.GraphFormats, opts.outputs.Graph) {
+		err = fmt.Errorf("--graph must be one of %s, not %q", strings.Join(engine.GraphFormats, ", "), opts.outputs.Graph)
+	}
+	if err == nil && opts.outputs.Render.Voronoi != "" && !contains(VoronoiModes, opts.outputs.Render.Voronoi) {
+		err = fmt.Errorf("--voronoiOverlay must be one of %s, not %q", strings.Join(VoronoiModes, ", "), opts.outputs.Render.Voronoi)
+	}
+	if err == nil && opts.outputs.Render.Color != "" && !contains(ColorAttributes, opts.outputs.Render.Color) {
+		err = fmt.Errorf("--color must be one of %s, not %q", strings.Join(ColorAttributes, ", "), opts.outputs.Render.Color)
+	}
+	if err == nil && opts.outputs.Render.ColorMap != "" && !contains(ColorMaps, opts.outputs.Render.ColorMap) {
+		err = fmt.Errorf("--colorMap must be one of %s, not %q", strings.Join(ColorMaps, ", "), opts.outputs.Render.ColorMap)
+	}
+	if err == nil && !(opts.outputs.Render.Radius > 0) {
+		err = fmt.Errorf("--cellRadius must be above 0, not %g", opts.outputs.Render.Radius)
+	}
+	if err == nil && !(opts.outputs.Render.Scale > 0) {
+		err = fmt.Errorf("--scale must be above 0, not %g", opts.outputs.Render.Scale)
+	}
+	if err == nil {
+		err = opts.outputs.Frames.validate()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Run \"%s --help\" for usage.\n", opts.flags.Name())
+		return 2, false
+	}
+	return 0, true
+}
+
+/*
+	loadConfig reads the config file, applies every config key given as a flag on top
+	of it, and picks a seed if neither gave one.
+*/
+
+func (opts *runOptions) loadConfig() engine.SimulationConfig {
+	cfg := MustLoadConfig(opts.config)
+	opts.applyOverrides(&cfg)
+	cfg.ResolveSeed()
+	return cfg
+}
+
+// applyOverrides sets every config key given as a flag on cfg, and exits if any of them is invalid
+func (opts *runOptions) applyOverrides(cfg *engine.SimulationConfig) {
+	var errs engine.ConfigErrors
+	opts.flags.Visit(func(f *flag.Flag) {
+		if value, ok := opts.overrides[f.Name]; ok {
+			if err := cfg.Override(f.Name, *value); err != nil {
+				errs = append(errs, err.(engine.ConfigErrors)...)
+			}
+		}
+	})
+	if len(errs) > 0 {
+		MustValidate(errs)
+	}
+}
+
+// output creates the output directory and returns the output path without its extension
+func (opts *runOptions) output() string {
+	if err := os.MkdirAll(opts.out, 0755); err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	return filepath.Join(opts.out, opts.name)
+}
+
+// contains reports whether list holds s
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunCLIDispatch(t *testing.T) {
+	for _, tc := range []struct {
+		args   []string
+		status int
+	}{
+		{nil, 2},
+		{[]string{"help"}, 0},
+		{[]string{"--help"}, 0},
+		{[]string{"simulate"}, 2},
+		{[]string{"run"}, 2},
+		{[]string{"run", "threecluster"}, 2},
+		{[]string{"run", "onecluster", "--help"}, 0},
+		{[]string{"run", "onecluster", "extra"}, 2},
+		{[]string{"run", "onecluster", "--radius", "3"}, 2},
+		{[]string{"run", "onecluster", "--trajectory", "xml"}, 2},
+		{[]string{"run", "onecluster", "--scale", "0"}, 2},
+		{[]string{"run", "twocluster", "--numZones", "2"}, 2},
+		{[]string{"run", "twocluster", "--addmaze", "1"}, 2},
+		{[]string{"autogen", "--help"}, 0},
+		{[]string{"resume"}, 2},
+		{[]string{"resume", "--numGens", "5"}, 2},
+		{[]string{"resume", "run.checkpoint.json", "--strategy", "Voronoi"}, 2},
+		{[]string{"bench", "--sizes", "many"}, 2},
+	} {
+		if status := RunCLI(tc.args); status != tc.status {
+			t.Errorf("cgsimu %q: status %d, want %d", tc.args, status, tc.status)
+		}
+	}
+}
+
+// writeConfig writes a config file of data to dir and returns its name
+func writeConfig(t *testing.T, dir, name, data string) string {
+	t.Helper()
+	filename := filepath.Join(dir, name)
+	if err := os.WriteFile(filename, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestFlagsOverrideConfig(t *testing.T) {
+	dir := t.TempDir()
+	for _, config := range []string{
+		writeConfig(t, dir, "in.txt", "numGens: 30\nsearchRadius: 12\nstrategy: Voronoi\nseed: 4\n"),
+		writeConfig(t, dir, "in.json", `{"numGens": 30, "searchRadius": 12, "strategy": "Voronoi", "seed": 4}`),
+	} {
+		opts := newRunOptions("run onecluster", "OneClusterInputs.txt", "OneCluster", nil)
+		if status, ok := opts.parse([]string{"--config", config, "--numGens", "7", "--strategy", "knn", "--out", dir}); !ok {
+			t.Fatalf("%s: status %d", config, status)
+		}
+		cfg := opts.loadConfig()
+		if cfg.NumGens != 7 || cfg.Strategy != "KNN" {
+			t.Errorf("%s: flags give numGens %d and strategy %q, want 7 and KNN", config, cfg.NumGens, cfg.Strategy)
+		}
+		if cfg.SearchRadius != 12 || cfg.Seed != 4 {
+			t.Errorf("%s: searchRadius %g and seed %d, want the 12 and 4 of the file", config, cfg.SearchRadius, cfg.Seed)
+		}
+		if opts.output() != filepath.Join(dir, "OneCluster") {
+			t.Errorf("%s: output %q, want OneCluster in %s", config, opts.output(), dir)
+		}
+	}
+}
+
+func TestResumeFlags(t *testing.T) {
+	opts := newResumeOptions()
+	for name := range opts.overrides {
+		if !resumeKeys[name] {
+			t.Errorf("resume has a flag for %s, which would change the boards", name)
+		}
+	}
+	for name := range resumeKeys {
+		if opts.overrides[name] == nil {
+			t.Errorf("resume has no flag for %s", name)
+		}
+	}
+	if _, ok := newResumeOptions().parse([]string{"--numGens", "20", "--checkpointEvery", "5"}); !ok {
+		t.Error("resume refuses --numGens and --checkpointEvery")
+	}
+	if _, ok := newResumeOptions().parse([]string{"--seed", "3"}); ok {
+		t.Error("resume accepts --seed")
+	}
+}
+
+func TestResumedName(t *testing.T) {
+	for _, tc := range []struct {
+		filename, model, want string
+	}{
+		{"out/OneCluster.checkpoint.json", "onecluster", "OneCluster_resumed"},
+		{"Run7.checkpoint.json", "twocluster", "Run7_resumed"},
+		{"saved.json", "onecluster", "OneCluster_resumed"},
+		{"saved.json", "twocluster", "TwoClusterSS_resumed"},
+	} {
+		if got := resumedName(tc.filename, tc.model); got != tc.want {
+			t.Errorf("resumedName(%q, %q) = %q, want %q", tc.filename, tc.model, got, tc.want)
+		}
+	}
+}
+
+func TestRunAndResume(t *testing.T) {
+	dir := t.TempDir()
+	config := writeConfig(t, dir, "in.txt", "initialcells: 20\nnumGens: 6\nseed: 3\n")
+	if status := RunCLI([]string{"run", "onecluster", "--config", config, "--out", dir, "--name", "run", "--checkpointEvery", "3"}); status != 0 {
+		t.Fatalf("run: status %d", status)
+	}
+	checkpoint := filepath.Join(dir, "run.checkpoint.json")
+	if _, err := os.Stat(checkpoint); err != nil {
+		t.Fatal(err)
+	}
+	if status := RunCLI([]string{"resume", checkpoint, "--numGens", "9", "--out", dir}); status != 0 {
+		t.Fatalf("resume: status %d", status)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "run_resumed.gif")); err != nil {
+		t.Errorf("resume wrote no frames: %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"image/color"
+	"math"
+)
+
+// ColorMaps are the colour maps Render.ColorMap can name
+var ColorMaps = []string{"viridis", "magma", "diverging", "categorical"}
+
+/*
+	colorMap turns values into colours. A continuous map blends its stops evenly over
+	values from 0 to 1; a categorical one gives each whole value the stop it falls on,
+	taken round the stops again past the last.
+*/
+
+type colorMap struct {
+	stops       [][3]float64
+	categorical bool
+}
+
+var colorMaps = map[string]colorMap{
+	"viridis": {stops: [][3]float64{
+		{68, 1, 84}, {71, 44, 122}, {59, 81, 139}, {44, 113, 142}, {33, 144, 141},
+		{39, 173, 129}, {92, 200, 99}, {170, 220, 50}, {253, 231, 37},
+	}},
+	"magma": {stops: [][3]float64{
+		{0, 0, 4}, {28, 16, 68}, {79, 18, 123}, {129, 37, 129}, {181, 54, 122},
+		{229, 80, 100}, {251, 135, 97}, {254, 194, 135}, {252, 253, 191},
+	}},
+	// blue below the middle of the scale and red above it
+	"diverging": {stops: [][3]float64{
+		{5, 48, 97}, {33, 102, 172}, {67, 147, 195}, {146, 197, 222}, {247, 247, 247},
+		{244, 165, 130}, {214, 96, 77}, {178, 24, 43}, {103, 0, 31},
+	}},
+	"categorical": {categorical: true, stops: [][3]float64{
+		{31, 119, 180}, {255, 127, 14}, {44, 160, 44}, {214, 39, 40}, {148, 103, 189},
+		{140, 86, 75}, {227, 119, 194}, {127, 127, 127}, {188, 189, 34}, {23, 190, 207},
+	}},
+}
+
+// at returns the colour of v: from 0 to 1 for a continuous map, a whole number for a categorical one
+func (m colorMap) at(v float64) color.Color {
+	if m.categorical {
+		k := int(math.Floor(v)) % len(m.stops)
+		if k < 0 {
+			k += len(m.stops)
+		}
+		s := m.stops[k]
+		return MakeColor(uint8(s[0]), uint8(s[1]), uint8(s[2]))
+	}
+	t := math.Max(0, math.Min(1, v)) * float64(len(m.stops)-1)
+	k := int(t)
+	if k == len(m.stops)-1 {
+		k--
+	}
+	f := t - float64(k)
+	mix := func(i int) uint8 { return uint8(m.stops[k][i] + f*(m.stops[k+1][i]-m.stops[k][i]) + 0.5) }
+	return MakeColor(mix(0), mix(1), mix(2))
+}
+
+/*
+	colorScale gives the colours of the values of an attribute: a continuous map spreads
+	lo to hi over the map, and a categorical one colours each value by itself.
+*/
+
+type colorScale struct {
+	colorMap
+	name   string
+	lo, hi float64
+}
+
+// color returns the colour of v on the scale
+func (s colorScale) color(v float64) color.Color {
+	if s.categorical {
+		return s.at(v)
+	}
+	if s.hi <= s.lo {
+		return s.at(0.5)
+	}
+	return s.at((v - s.lo) / (s.hi - s.lo))
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"cgsimu/engine"
+)
+
+// VoronoiModes are the ways Render.Voronoi can draw the Voronoi cells under the cells
+var VoronoiModes = []string{"outline", "area", "density"}
+
+// ColorAttributes are the attributes of a cell Render.Color can colour it by
+var ColorAttributes = []string{"density", "age", "lineage", "signalLevel", "celltype"}
+
+/*
+	Render is how the boards of a run are drawn into its gif. Voronoi, when set, draws
+	the Voronoi cell of every cell, clipped to the board, under the cells: outline only
+	strokes the polygons, area also fills each one by its area on a log scale, and
+	density by the density of its cell, from the smallest value of the generation to the
+	largest on the colour map.
+
+	Color, when set, colours every cell by an attribute through ColorMap instead of the
+	usual colours: its density, its age, its lineage (the id of the founder of its clone),
+	its signal level or its celltype. A continuous map spreads the values of each
+	generation from the smallest to the largest, except signal levels and celltypes,
+	which keep their whole range; a categorical map gives each value its own colour.
+	Without a ColorMap, lineage and celltype are categorical and the others viridis.
+
+	The zones of a one cluster board are drawn as translucent discs, green where they
+	promote birth and red where they inhibit it, the stronger the more opaque, and the
+	walls of its maze as grey rectangles, unless HideZones or HideMaze is set.
+
+	Scale sets the size of the frames relative to the board, so that a board 500 wide
+	is drawn 1000 pixels wide with a Scale of 2; the cells, zones and walls are scaled
+	with it, the legend is not.
+*/
+
+type Render struct {
+	Voronoi  string  // "", or one of VoronoiModes
+	Color    string  // "", or one of ColorAttributes
+	ColorMap string  // "", or one of ColorMaps
+	Radius   float64 // radius the cells are drawn with, 1 if 0
+	Legend   bool    // draw the colour bar, or the key of the categories, of Color
+
+	HideZones bool // leave the zones out
+	HideMaze  bool // leave the maze out
+
+	Scale float64 // pixels per unit of the board, 1 if 0
+}
+
+// newFrame returns a black canvas for a board of the given width, scaled by r.Scale
+func (r Render) newFrame(width float64) Canvas {
+	scale := r.Scale
+	if scale <= 0 {
+		scale = 1
+	}
+	size := int(width * scale)
+	c := CreateNewCanvas(size, size)
+
+	c.SetFillColor(MakeColor(0, 0, 0))
+	c.ClearRect(0, 0, size, size)
+	c.Fill()
+	c.Scale(scale, scale)
+	return c
+}
+
+// radius returns the radius the cells are drawn with
+func (r Render) radius() float64 {
+	if r.Radius > 0 {
+		return r.Radius
+	}
+	return 1
+}
+
+// colorMap returns the map of r.ColorMap, or the usual one for r.Color without it
+func (r Render) colorMap() colorMap {
+	if m, ok := colorMaps[r.ColorMap]; ok {
+		return m
+	}
+	if r.Color == "lineage" || r.Color == "celltype" {
+		return colorMaps["categorical"]
+	}
+	return colorMaps["viridis"]
+}
+
+// DrawBoard takes in a board and draw a image
+func DrawBoard(board engine.GameBoard, r Render) Canvas {
+	c := r.newFrame(board.Width())
+
+	cells := board.Cells()
+	if r.Voronoi != "" {
+		drawVoronoi(&c, cells, board.Width(), r)
+	}
+	if !r.HideZones {
+		drawZones(&c, board.Zones())
+	}
+	if !r.HideMaze {
+		drawMaze(&c, board.Maze())
+	}
+	if r.Color != "" {
+		drawCells(&c, cells, r)
+		return c
+	}
+	for i := range cells {
+		c.SetFillColor(MakeColor(255, 255, 255))
+		c.Circle(cells[i].X(), cells[i].Y(), r.radius())
+		c.Fill()
+	}
+	return c
+}
+
+/*
+	DrawTwoClusterBoard takes a board as input, and returns a Canvas with the drawing board
+*/
+
+func DrawTwoClusterBoard(board engine.TwoClusterBoard, r Render) Canvas {
+	c := r.newFrame(board.Width())
+
+	all := append(append([]engine.Cell(nil), board.Sources()...), board.Sinks()...)
+	if r.Voronoi != "" {
+		drawVoronoi(&c, all, board.Width(), r)
+	}
+	if r.Color != "" {
+		drawCells(&c, all, r)
+		return c
+	}
+
+	/*
+		The source cell shows a blue color, and the sink cell shows different levels of
+		yellowness according to its signal level
+	*/
+
+	for _, cells := range [][]engine.Cell{board.Sources(), board.Sinks()} {
+		for j := range cells {
+			if cells[j].CellType() == 1 { // source
+				c.SetFillColor(MakeColor(38, 226, 220))
+			} else if cells[j].CellType() == 2 && cells[j].SignalLevel() == 0 { // sink
+				c.SetFillColor(MakeColor(105, 105, 0))
+			} else if cells[j].CellType() == 2 && cells[j].SignalLevel() == 1 {
+				c.SetFillColor(MakeColor(155, 155, 0))
+			} else if cells[j].CellType() == 2 && cells[j].SignalLevel() == 2 {
+				c.SetFillColor(MakeColor(205, 205, 0))
+			} else if cells[j].CellType() == 2 && cells[j].SignalLevel() == 3 {
+				c.SetFillColor(MakeColor(255, 255, 0))
+			}
+			c.Circle(cells[j].X(), cells[j].Y(), r.radius())
+			c.Fill()
+		}
+	}
+	return c
+}
+
+/*
+	drawVoronoi draws the Voronoi cell of each of cells in the mode r.Voronoi, filled
+	with a continuous colour map: r.ColorMap, unless it is categorical.
+	The polygons are found again for the places the cells are drawn at, since the edges
+	the Voronoi strategy leaves on the cells were found before they moved.
+*/
+
+func drawVoronoi(c *Canvas, cells []engine.Cell, width float64, r Render) {
+	mode := r.Voronoi
+	scale := colorScale{colorMap: colorMaps[r.ColorMap]}
+	if scale.categorical || scale.stops == nil {
+		scale.colorMap = colorMaps["viridis"]
+	}
+	polygons := engine.Polygons(cells, width)
+	values := voronoiValues(cells, polygons, mode)
+	scale.lo, scale.hi = math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		scale.lo, scale.hi = math.Min(scale.lo, v), math.Max(scale.hi, v)
+	}
+
+	c.SetLineWidth(1)
+	c.SetStrokeColor(MakeColor(90, 90, 90))
+	for i, polygon := range polygons {
+		if polygon.Len() < 3 {
+			continue
+		}
+		x, y := polygon.Vertex(0)
+		c.MoveTo(x, y)
+		for k := 1; k < polygon.Len(); k++ {
+			c.LineTo(polygon.Vertex(k))
+		}
+		c.LineTo(x, y)
+		if mode == "outline" {
+			c.Stroke()
+			continue
+		}
+		c.SetFillColor(scale.color(values[i]))
+		c.FillStroke()
+	}
+}
+
+/*
+	voronoiValues returns the value each of cells fills its polygon with in mode: the log
+	of the area of its polygon, or its density, which the boards of a run carry as
+	measured for the places they are drawn at.
+*/
+
+func voronoiValues(cells []engine.Cell, polygons []engine.Polygon, mode string) []float64 {
+	values := make([]float64, len(cells))
+	for i := range cells {
+		switch mode {
+		case "area":
+			values[i] = math.Log(math.Max(polygons[i].Area(), 1e-9))
+		case "density":
+			values[i] = cells[i].Density()
+		}
+	}
+	return values
+}
+
+// drawZones draws each zone as a disc, green if its strength is positive and red if not, as opaque as it is strong
+func drawZones(c *Canvas, zones []engine.Zone) {
+	for _, zone := range zones {
+		alpha := 40 + 120*math.Min(math.Abs(zone.Strength()), 1)
+		tint := color.RGBA{0, uint8(200 * alpha / 255), 0, uint8(alpha)}
+		if zone.Strength() < 0 {
+			tint = color.RGBA{uint8(220 * alpha / 255), 0, 0, uint8(alpha)}
+		}
+		x, y := zone.Centre()
+		c.SetFillColor(tint)
+		c.Circle(x, y, zone.Radius())
+		c.Fill()
+	}
+}
+
+// drawMaze draws each wall of the maze as a grey rectangle
+func drawMaze(c *Canvas, walls []engine.Rectangle) {
+	c.SetFillColor(MakeColor(110, 110, 110))
+	for _, wall := range walls {
+		c.MoveTo(wall.X(), wall.Y())
+		c.LineTo(wall.X()+wall.Width(), wall.Y())
+		c.LineTo(wall.X()+wall.Width(), wall.Y()+wall.Height())
+		c.LineTo(wall.X(), wall.Y()+wall.Height())
+		c.LineTo(wall.X(), wall.Y())
+		c.Fill()
+	}
+}
+
+/*
+	drawCells draws cells coloured by the attribute r.Color through the colour map of r,
+	and its legend if r.Legend is set.
+*/
+
+func drawCells(c *Canvas, cells []engine.Cell, r Render) {
+	scale := colorScale{colorMap: r.colorMap(), name: r.Color}
+	values := make([]float64, len(cells))
+	scale.lo, scale.hi = math.Inf(1), math.Inf(-1)
+	for i := range cells {
+		values[i] = attribute(cells[i], r.Color)
+		scale.lo, scale.hi = math.Min(scale.lo, values[i]), math.Max(scale.hi, values[i])
+	}
+	switch r.Color {
+	case "signalLevel":
+		scale.lo, scale.hi = 0, 3
+	case "celltype":
+		scale.lo, scale.hi = 1, 2
+	}
+
+	for i := range cells {
+		c.SetFillColor(scale.color(values[i]))
+		c.Circle(cells[i].X(), cells[i].Y(), r.radius())
+		c.Fill()
+	}
+	if r.Legend && len(cells) > 0 {
+		drawLegend(c, scale, values)
+	}
+}
+
+/*
+	attribute returns the value of the attribute name, one of ColorAttributes, of cell.
+	Its density is the one measured for the board it is drawn on.
+*/
+
+func attribute(cell engine.Cell, name string) float64 {
+	switch name {
+	case "density":
+		return cell.Density()
+	case "age":
+		return float64(cell.Age())
+	case "lineage":
+		return float64(cell.Founder())
+	case "signalLevel":
+		return float64(cell.SignalLevel())
+	case "celltype":
+		return float64(cell.CellType())
+	}
+	return 0
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+
+	"cgsimu/engine"
+)
+
+// measuredDensities returns the density the estimator of cfg gives each of cells, by id, scored as the source and sink model scores it if scored
+func measuredDensities(cells []engine.Cell, width float64, cfg engine.SimulationConfig, scored bool) map[int]float64 {
+	estimator, _ := engine.LookupDensity(cfg.Strategy)
+	cells = estimator.Density(append([]engine.Cell(nil), cells...), width, cfg.Params())
+	if scorer, ok := estimator.(engine.GrowthScorer); ok && scored {
+		cells = scorer.GrowthScores(cells)
+	}
+	densities := make(map[int]float64, len(cells))
+	for _, c := range cells {
+		densities[c.ID()] = c.Density()
+	}
+	return densities
+}
+
+// checkDrawnDensities checks that --color density and --voronoiOverlay density colour cells by want, and not all alike
+func checkDrawnDensities(t *testing.T, gen int, cells []engine.Cell, width float64, want map[int]float64) {
+	t.Helper()
+	overlay := voronoiValues(cells, engine.Polygons(cells, width), "density")
+	alike := true
+	for i, c := range cells {
+		if got := attribute(c, "density"); got != want[c.ID()] {
+			t.Fatalf("generation %d: cell %d is coloured by density %g, its board's is %g", gen, c.ID(), got, want[c.ID()])
+		}
+		if overlay[i] != want[c.ID()] {
+			t.Fatalf("generation %d: the polygon of cell %d is filled by density %g, its board's is %g", gen, c.ID(), overlay[i], want[c.ID()])
+		}
+		alike = alike && overlay[i] == overlay[0]
+	}
+	if alike && len(cells) > 1 {
+		t.Errorf("generation %d: every cell has the same colour", gen)
+	}
+}
+
+/*
+	TestDrawnDensity draws the boards of a run as the run does, and checks that the
+	density colours are those of the board drawn, measured for the places its cells are
+	drawn at, from the first frame on.
+*/
+
+func TestDrawnDensity(t *testing.T) {
+	for _, strategy := range engine.DensityStrategies() {
+		t.Run(strategy, func(t *testing.T) {
+			cfg := engine.DefaultConfig()
+			cfg.InitialCells, cfg.BirthRadius, cfg.Strategy = 60, 40, strategy
+			board := engine.InitializeBoard(cfg.InitialCells, cfg.BirthRadius, cfg.Width, rand.New(engine.NewSource(3)))
+			engine.UpdateBoardStream(board, 4, cfg.Params(), func(gen int, b engine.GameBoard) error {
+				checkDrawnDensities(t, gen, b.Cells(), b.Width(), measuredDensities(b.Cells(), b.Width(), cfg, false))
+				return nil
+			})
+		})
+	}
+}
+
+func TestDrawnDensityTwoCluster(t *testing.T) {
+	for _, strategy := range engine.DensityStrategies() {
+		t.Run(strategy, func(t *testing.T) {
+			cfg := engine.DefaultConfig()
+			cfg.InitialCells, cfg.BirthRadius, cfg.Strategy = 60, 40, strategy
+			board := engine.InitializeTwoClusterBoard(cfg.InitialCells, cfg.BirthRadius, cfg.Width, rand.New(engine.NewSource(3)))
+			board.UpdateBoardStream(4, cfg.Params(), func(gen int, b engine.TwoClusterBoard) error {
+				want := measuredDensities(b.Sources(), b.Width(), cfg, true)
+				for id, d := range measuredDensities(b.Sinks(), b.Width(), cfg, true) {
+					want[id] = d
+				}
+				all := append(append([]engine.Cell(nil), b.Sources()...), b.Sinks()...)
+				checkDrawnDensities(t, gen, all, b.Width(), want)
+				return nil
+			})
+		})
+	}
+}
@@ -0,0 +1,833 @@
+/*
+	Package engine simulates the growth of clusters of cells on a square board.
+
+	A GameBoard holds one cluster of cells, together with the zones and the maze
+	that affect where new cells can be born; UpdateBoard runs it for a number of
+	generations. A TwoClusterBoard holds the source and sink model, where a
+	cluster of source cells sends signal to a cluster of sink cells. The
+	parameters of a run can be read from a config file with LoadConfig.
+*/
+package engine
+
+import (
+	"math"
+	"math/rand"
+)
+
+type Cell struct {
+	id          int // unique on its board, from 1, and kept for the life of the cell
+	parent      int // id of the cell it was born from, 0 for the cells a board starts with
+	born        int // generation it was born in
+	depth       int // number of births between it and the cell its lineage started from
+	founder     int // id of the cell its lineage started from, its own id for the cells a board starts with
+	age         int // generations the cell has lived through since it was born
+	celltype    int
+	x, y        float64
+	density     float64
+	signalLevel int
+	edges []*VEdge
+}
+
+type GameBoard struct {
+	cells      []Cell
+	width      float64
+	zone       []Zone
+	maze       []Rectangle
+	rng        *rand.Rand
+	generation int
+	births     int // cells born in the update that made the board
+	deaths     int // cells that died in the update that made the board
+	nextID     int // the last id given to a cell
+}
+
+type Rectangle struct {
+	x, y          float64
+	width, height float64
+}
+
+type Zone struct {
+	shape    string
+	strength float64
+	centrex  float64
+	centrey  float64
+	radius   float64
+}
+
+// NewCell returns a cell at (x, y)
+func NewCell(x, y float64) Cell {
+	var c Cell
+	c.x = x
+	c.y = y
+	return c
+}
+
+// ID returns the id of the cell, which stays the same from the generation it is born in until it dies
+func (c Cell) ID() int { return c.id }
+
+// Parent returns the id of the cell this one was born from, or 0 if it was on the board from the start
+func (c Cell) Parent() int { return c.parent }
+
+// Born returns the generation the cell was born in
+func (c Cell) Born() int { return c.born }
+
+// Depth returns how many generations of descent separate the cell from the founder of its lineage
+func (c Cell) Depth() int { return c.depth }
+
+// Founder returns the id of the cell its lineage started from, which is its own id if it was on the board from the start
+func (c Cell) Founder() int { return c.founder }
+
+// Age returns the number of generations the cell has lived through
+func (c Cell) Age() int { return c.age }
+
+// descend makes c a daughter of parent, born in generation
+func (c *Cell) descend(parent Cell, generation int) {
+	c.parent = parent.id
+	c.founder = parent.founder
+	c.born = generation
+	c.depth = parent.depth + 1
+}
+
+// X returns the x position of the cell
+func (c Cell) X() float64 { return c.x }
+
+// Y returns the y position of the cell
+func (c Cell) Y() float64 { return c.y }
+
+// Density returns the density around the cell counted in its last update
+func (c Cell) Density() float64 { return c.density }
+
+// CellType returns 0 for a cell of a GameBoard, 1 for a source and 2 for a sink
+func (c Cell) CellType() int { return c.celltype }
+
+// SignalLevel returns the signal a sink cell has received, from 0 to 3
+func (c Cell) SignalLevel() int { return c.signalLevel }
+
+/*
+	NewGameBoard returns a board of the given width holding cells, with no zones and
+	no maze. Every random choice the board makes is drawn from rng.
+*/
+
+func NewGameBoard(cells []Cell, width float64, rng *rand.Rand) GameBoard {
+	var board GameBoard
+	board.cells = append([]Cell(nil), cells...)
+	board.width = width
+	board.rng = rng
+	numberCells(board.cells, 0, &board.nextID)
+	return board
+}
+
+// Cells returns the cells on the board; the slice is shared with the board and must not be modified
+func (board GameBoard) Cells() []Cell { return board.cells }
+
+// Generation returns the number of updates between the initial board and board
+func (board GameBoard) Generation() int { return board.generation }
+
+// Births returns the number of cells born in the update that made board
+func (board GameBoard) Births() int { return board.births }
+
+// Deaths returns the number of cells that died in the update that made board
+func (board GameBoard) Deaths() int { return board.deaths }
+
+// Width returns the width (and height) of the board
+func (board GameBoard) Width() float64 { return board.width }
+
+// Zones returns the zones on the board; the slice is shared with the board and must not be modified
+func (board GameBoard) Zones() []Zone { return board.zone }
+
+// Maze returns the walls of the maze on the board; the slice is shared with the board and must not be modified
+func (board GameBoard) Maze() []Rectangle { return board.maze }
+
+// SetZones returns a copy of board with zones in place of its zones
+func (board GameBoard) SetZones(zones []Zone) GameBoard {
+	board.zone = append([]Zone(nil), zones...)
+	return board
+}
+
+// SetMaze returns a copy of board with walls as its maze
+func (board GameBoard) SetMaze(walls []Rectangle) GameBoard {
+	board.maze = append([]Rectangle(nil), walls...)
+	return board
+}
+
+// NewRectangle returns a rectangle with its corner at (x, y)
+func NewRectangle(x, y, width, height float64) Rectangle {
+	return Rectangle{x, y, width, height}
+}
+
+// X returns the x position of the corner of the rectangle
+func (r Rectangle) X() float64 { return r.x }
+
+// Y returns the y position of the corner of the rectangle
+func (r Rectangle) Y() float64 { return r.y }
+
+// Width returns the width of the rectangle
+func (r Rectangle) Width() float64 { return r.width }
+
+// Height returns the height of the rectangle
+func (r Rectangle) Height() float64 { return r.height }
+
+/*
+	NewZone returns a circle zone centred at (centrex, centrey). A positive strength
+	promotes birth inside the zone and a negative strength inhibits it; strength is
+	added to the survival rate of a new cell, which is limited to [0, 2].
+*/
+
+func NewZone(centrex, centrey, radius, strength float64) Zone {
+	var z Zone
+	z.shape = "circle"
+	z.strength = strength
+	z.centrex = centrex
+	z.centrey = centrey
+	z.radius = radius
+	return z
+}
+
+// Shape returns the shape of the zone, which is always "circle"
+func (z Zone) Shape() string { return z.shape }
+
+// Strength returns how much the zone promotes (> 0) or inhibits (< 0) birth
+func (z Zone) Strength() float64 { return z.strength }
+
+// Centre returns the centre of the zone
+func (z Zone) Centre() (float64, float64) { return z.centrex, z.centrey }
+
+// Radius returns the radius of the zone
+func (z Zone) Radius() float64 { return z.radius }
+
+//AddZone takes in numZones and generate numZones zone randomly
+func (board GameBoard) AddZone(numZones int) GameBoard {
+	//create slice of circle zones
+	board.zone = make([]Zone, numZones)
+	for i := 0; i < len(board.zone); i++ {
+		board.zone[i] = CircleZone(board.width, board.rng)
+	}
+
+	return board
+}
+
+//CircleZone takes in board width and random generate a zone in board using rng
+func CircleZone(width float64, rng *rand.Rand) Zone {
+	var circle Zone
+	circle.shape = "circle"
+	//random dicide inhibit or improve strength
+	circle.strength = rng.Float64()*2 - 1
+	circle.centrex = rng.Float64() * width
+	circle.centrey = rng.Float64() * width
+	circle.radius = rng.Float64() * 50
+
+	return circle
+}
+
+//MakeMaze define rectangles in board and build up a maze
+func (board GameBoard) MakeMaze() GameBoard {
+	board.maze = make([]Rectangle, 6)
+
+	board.maze[0].x = 0
+	board.maze[0].y = 0
+	board.maze[0].width = board.width / 6
+	board.maze[0].height = board.width
+
+	board.maze[1].x = 0
+	board.maze[1].y = 5 * board.width / 6
+	board.maze[1].width = board.width
+	board.maze[1].height = board.width / 6
+
+	board.maze[2].x = 5 * board.width / 6
+	board.maze[2].y = 0
+	board.maze[2].width = board.width / 6
+	board.maze[2].height = board.width
+
+	board.maze[3].x = 2 * board.width / 6
+	board.maze[3].y = 0
+	board.maze[3].width = 4 * board.width / 6
+	board.maze[3].height = board.width / 6
+
+	board.maze[4].x = 2 * board.width / 6
+	board.maze[4].y = 0
+	board.maze[4].width = board.width / 6
+	board.maze[4].height = 4 * board.width / 6
+
+	board.maze[5].x = 2 * board.width / 6
+	board.maze[5].y = 3 * board.width / 6
+	board.maze[5].width = 2 * board.width / 6
+	board.maze[5].height = board.width / 6
+
+	return board
+}
+
+// GenerateCell takes width as input, and returns a randomly-generated cell
+// within a circle of radius width/2
+func (board GameBoard) GenerateCell(centerX, centerY, radius float64) Cell {
+	//make random angel and radius
+	a := board.rng.Float64() * 2.0 * math.Pi
+	r := (2.0*board.rng.Float64() - 1.0) * radius
+
+	//make a new cell and difine its location
+	var newCell Cell
+	newCell.x = r*math.Cos(a) + centerX
+	newCell.y = r*math.Sin(a) + centerY
+
+	//check bondary
+	if newCell.x < 0.0 {
+		newCell.x = 0.0
+	}
+	if newCell.x > board.width {
+		newCell.x = board.width
+	}
+	if newCell.y < 0.0 {
+		newCell.y = 0.0
+	}
+	if newCell.y > board.width {
+		newCell.y = board.width
+	}
+	return newCell
+}
+
+/*
+	CalculateDistance takes c1 and c2 as input, and returns the distance between the two cells
+	using Euclidean method.
+*/
+
+func CalculateDistance(c1, c2 Cell) float64 {
+	c1x := c1.x
+	c1y := c1.y
+	c2x := c2.x
+	c2y := c2.y
+	return math.Sqrt((c1x-c2x)*(c1x-c2x) + (c1y-c2y)*(c1y-c2y))
+}
+
+// InitializeBoard takes numCells, birthRadius, width and rng as inputs, and returns GameBoard
+// with randomly-generated cells within the radius of birthRadius. Every random choice
+// the board makes from then on is drawn from rng.
+func InitializeBoard(numCells int, birthRadius, width float64, rng *rand.Rand) GameBoard {
+	//make an initial board
+	var board GameBoard
+	board.cells = make([]Cell, numCells)
+	board.width = width
+	board.rng = rng
+
+	//randomly generate numCells cells in board
+	for i := 0; i <= numCells-1; i++ {
+		board.cells[i] = board.GenerateCell(board.width/2, board.width/2, birthRadius)
+	}
+	numberCells(board.cells, 0, &board.nextID)
+	return board
+}
+
+// numberCells gives each of cells[from:] the id after *last, and leaves *last at the last id given;
+// a cell with no parent founds its own lineage
+func numberCells(cells []Cell, from int, last *int) {
+	for i := from; i < len(cells); i++ {
+		*last++
+		cells[i].id = *last
+		if cells[i].parent == 0 {
+			cells[i].founder = cells[i].id
+		}
+	}
+}
+
+// Params are the parameters of each update of a GameBoard or TwoClusterBoard
+type Params struct {
+	SearchRadius float64 // radius used to count the density around a cell
+	BirthRadius  float64 // radius around a cell where a new cell can be born
+	DeathRadius  float64 // cells closer than this compete and the denser one dies
+	BirthRate    float64 // fraction of the least dense cells that give birth
+	DeathRate    float64 // fraction of the densest cells that repel their neighbours
+	Strategy     string  // DensityEstimator used, by its registered name: "CountDensity" (or ""), "Voronoi", ...
+	Index        string  // SpatialIndex used for radius queries: "grid" (or "") or "brute"
+	Workers      int     // goroutines sharing the per-cell work; 1 (or 0) runs it on the calling goroutine
+	DeathMode    string  // what kills cells: "crowding" (or ""), "senescence" or "both"
+	BirthMode    string  // how births draw their random numbers: "serial" (or "") or "chunked"
+	Kernel       string  // kernel of the Kernel strategy: "gaussian" (or "") or "epanechnikov"
+	Bandwidth    float64 // bandwidth of the Kernel strategy; 0 uses SearchRadius
+	K            int     // neighbour whose distance sets the density in the KNN strategy
+}
+
+// UpdateBoard takes an initialBoard, numGens and the update Params as inputs,
+// and returns the numGens+1 boards from initialBoard to the last generation
+func UpdateBoard(initialBoard GameBoard, numGens int, p Params) []GameBoard {
+	boards := make([]GameBoard, 0)
+	UpdateBoardStream(initialBoard, numGens, p, func(gen int, board GameBoard) error {
+		boards = append(boards, board)
+		return nil
+	})
+	return boards
+}
+
+// UpdateBoardStream updates initialBoard numGens times like UpdateBoard, but hands every board,
+// starting with initialBoard, to fn as soon as it is made instead of keeping it, so a long run
+// only holds one generation at a time. gen is the Generation of the board, which carries on
+// from initialBoard's when it was resumed from a checkpoint. Every board fn is given has the
+// density of its own cells, the one its update goes by. It stops at, and returns, the first
+// error fn returns.
+func UpdateBoardStream(initialBoard GameBoard, numGens int, p Params, fn func(gen int, board GameBoard) error) error {
+	board := initialBoard.measured(p)
+	for i := 0; i <= numGens; i++ {
+		if i > 0 {
+			board = board.advance(p).measured(p)
+		}
+		if err := fn(board.generation, board); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateOneBoard takes a currentBoard and the update Params as inputs, and returns an updated board
+func (currentBoard GameBoard) UpdateOneBoard(p Params) GameBoard {
+	return currentBoard.measured(p).advance(p)
+}
+
+// measured returns board with the density of each of its cells set by the DensityEstimator of p,
+// on a copy of its cells, so that a board already handed out keeps the densities it was given
+func (board GameBoard) measured(p Params) GameBoard {
+	board.cells = densityOf(p).Density(append([]Cell(nil), board.cells...), board.width, p)
+	return board
+}
+
+// advance makes the board of the next generation from currentBoard, whose densities are measured
+func (currentBoard GameBoard) advance(p Params) GameBoard {
+	searchRadius, birthRadius, deathRadius := p.SearchRadius, p.BirthRadius, p.DeathRadius
+	birthrate, deathrate := p.BirthRate, p.DeathRate
+
+	//make a new board
+	var newboard1 GameBoard
+	newboard1 = CopyBoard(currentBoard)
+	newboard1.generation++
+	ageCells(newboard1.cells)
+
+	//sort cells in newboard
+	newboard1.cells = Sorting(newboard1.cells)
+
+	//decide in currentboard cells, before which key cell need to born a new cell, and after which key cell need to die
+	birthkey := int(float64(len(currentBoard.cells)) * (1 - birthrate))
+	deathkey := int(float64(len(currentBoard.cells)) * deathrate)
+
+	//cell born and move and death, each with an index of the cells as they are at that point
+	parents := len(newboard1.cells)
+	if p.BirthMode == "chunked" {
+		newboard1.cells = newboard1.bornParallel(NewSpatialIndex(p.Index, newboard1.cells, newboard1.width, searchRadius), birthRadius, searchRadius, birthkey, p.Workers)
+	} else {
+		newboard1.cells = newboard1.born(NewSpatialIndex(p.Index, newboard1.cells, newboard1.width, searchRadius), birthRadius, searchRadius, birthkey)
+	}
+	newboard1.births = len(newboard1.cells) - parents
+	numberCells(newboard1.cells, parents, &newboard1.nextID)
+	newboard1.cells = newboard1.move(NewSpatialIndex(p.Index, newboard1.cells, newboard1.width, searchRadius), birthkey, deathkey, searchRadius, p.Workers)
+	newboard1.cells = Sorting(newboard1.cells)
+	if p.DeathMode != "senescence" {
+		newboard1.cells = survivors(newboard1.cells, deathRadius, NewSpatialIndex(p.Index, newboard1.cells, newboard1.width, searchRadius))
+	}
+	if p.DeathMode == "senescence" || p.DeathMode == "both" {
+		newboard1.cells = senescence(newboard1.cells, newboard1.rng)
+	}
+	newboard1.deaths = parents + newboard1.births - len(newboard1.cells)
+
+	return newboard1
+}
+
+//CopyBoard make a new board and copy all the properties of the input board
+func CopyBoard(board GameBoard) GameBoard {
+	//make a new board
+	var newboard GameBoard
+
+	//copy cells information from currentboard to newboard1 in a new store space
+	newboard.cells = make([]Cell, 0)
+	for i := range board.cells {
+		newboard.cells = append(newboard.cells, board.cells[i])
+	}
+
+	//copy zones
+	newboard.zone = make([]Zone, 0)
+	for i := range board.zone {
+		newboard.zone = append(newboard.zone, board.zone[i])
+	}
+
+	//copy maze
+	newboard.maze = make([]Rectangle, 0)
+	for i := range board.maze {
+		newboard.maze = append(newboard.maze, board.maze[i])
+	}
+
+	//copy properties
+	newboard.width = board.width
+	newboard.rng = board.rng
+	newboard.generation = board.generation
+	newboard.nextID = board.nextID
+
+	return newboard
+}
+
+//if distance between two cells is smaller than deathRadius, the cell with greater density died
+func (board GameBoard) Death(deathRadius float64) []Cell {
+	board.cells = Sorting(board.cells)
+	return survivors(board.cells, deathRadius, NewGrid(board.cells, board.width, deathRadius))
+}
+
+/*
+	survivors returns the cells, sorted from least to most dense, that survive Death.
+	Death walks the cells in order and removes a cell as soon as a later cell is within
+	deathRadius; the next cell then takes its place and carries on comparing from two
+	cells past the one that caused the death. index answers the radius queries instead
+	of comparing every pair, with the same result.
+*/
+
+func survivors(cells []Cell, deathRadius float64, index SpatialIndex) []Cell {
+	alive := make([]Cell, 0, len(cells))
+	var near []int
+	for cur := 0; cur < len(cells); cur++ {
+		next := cur + 1 // first cell cur is compared with
+		for {
+			near = index.Neighbours(cells[cur].x, cells[cur].y, deathRadius, near[:0])
+			hit := -1
+			for _, j := range near {
+				if j >= next {
+					hit = j
+					break
+				}
+			}
+			if hit < 0 {
+				break
+			}
+			// cur dies, and the cell after it is compared from two cells past hit
+			cur++
+			next = hit + 2
+		}
+		alive = append(alive, cells[cur])
+	}
+	return alive
+}
+
+//SurvivalRate takes in each cells' lifespan and calculate its correspoding survial rate
+func SurvivalRate(generation int) float64 {
+	survival := math.Pow(math.E, 1.1*(float64(generation)/3.2)-9.8)
+	return survival
+}
+
+/*
+	BirthModes are the ways births can draw their random numbers that Params.BirthMode
+	can name. serial, the default, draws every birth from the board's rng in turn,
+	counting the cells born before it, as every run did before workers were added, so
+	the seeds of those runs still reproduce. chunked gives each block of chunkSize
+	parents a random stream of its own and places them by the cells as they were before
+	any birth, so that the births too can be shared between workers; it is a different
+	model, with boards of its own for a seed.
+*/
+
+var BirthModes = []string{"serial", "chunked"}
+
+// knownBirthMode reports whether mode is one of BirthModes
+func knownBirthMode(mode string) bool {
+	for _, known := range BirthModes {
+		if mode == known {
+			return true
+		}
+	}
+	return false
+}
+
+// DeathModes are the ways cells can die that Params.DeathMode can name
+var DeathModes = []string{"crowding", "senescence", "both"}
+
+// knownDeathMode reports whether mode is one of DeathModes
+func knownDeathMode(mode string) bool {
+	for _, known := range DeathModes {
+		if mode == known {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+	Senescence takes a slice of Cell and rng, and returns the cells that survive their
+	age: a cell of age a dies with probability SurvivalRate(a), which is used as a hazard
+	that grows with age, from almost 0 for a newborn to certain death at about 29
+	generations. Unlike Death, it does not depend on where the cells are.
+*/
+
+func Senescence(cells []Cell, rng *rand.Rand) []Cell {
+	return senescence(append([]Cell(nil), cells...), rng)
+}
+
+// senescence is Senescence, keeping the survivors in cells
+func senescence(cells []Cell, rng *rand.Rand) []Cell {
+	alive := cells[:0]
+	for _, c := range cells {
+		if rng.Float64() >= SurvivalRate(c.age) {
+			alive = append(alive, c)
+		}
+	}
+	return alive
+}
+
+// ageCells makes every one of cells a generation older
+func ageCells(cells []Cell) {
+	for i := range cells {
+		cells[i].age++
+	}
+}
+
+//CountDensity takes a list of Cell and searchRadius as input, and returns a slice of Cell with density.
+func CountDensity(cells []Cell, searchRadius float64) []Cell {
+	return countDensity(cells, searchRadius, NewGrid(cells, extent(cells), searchRadius), 1)
+}
+
+//countDensity is CountDensity with the radius queries answered by index, shared by workers goroutines
+func countDensity(cells []Cell, searchRadius float64, index SpatialIndex, workers int) []Cell {
+	forChunks(len(cells), workers, func(_, lo, hi int) {
+		for i := lo; i < hi; i++ {
+			cells[i].density = float64(index.Count(cells[i].x, cells[i].y, searchRadius))
+			cells[i].density -= 1 //exclude itself
+		}
+	})
+	return cells
+}
+
+//extent returns the largest coordinate of cells, which bounds the board they are on
+func extent(cells []Cell) float64 {
+	width := 0.0
+	for i := range cells {
+		width = math.Max(width, math.Max(cells[i].x, cells[i].y))
+	}
+	return width
+}
+
+//Sorting takes a slice of Cell and create a order from most dense to least dense.
+func Sorting(cells []Cell) []Cell {
+	//make min heap
+	//i = parent index; a = child index
+	for j := len(cells)/2 - 1; j >= 0; j-- { //range from largest parent node to root
+		//heapify down
+		i := j
+		for 2*i+1 <= len(cells)-1 { //while cells[i] still have child, heapify down
+			a := 2*i + 1               //left child
+			if 2*i+2 <= len(cells)-1 { //if right child exists
+				if cells[2*i+2].density < cells[2*i+1].density {
+					a = 2*i + 2
+				}
+			}
+			if cells[i].density > cells[a].density {
+				cells[i], cells[a] = cells[a], cells[i] //if parent < child, heapify down
+				i = a
+			} else {
+				break
+			}
+		}
+	}
+
+	//Sorting
+	length := len(cells) - 1 //the last node's index
+	for length > 0 {         //while there's still nodes in heaps
+		cells[0], cells[length] = cells[length], cells[0] //swap root with the last node
+		length--
+		i := 0
+		for 2*i+1 <= length { //while cells[i] still have child, heapify down
+			a := 2*i + 1         //left child
+			if 2*i+2 <= length { //if right child exists
+				if cells[2*i+2].density < cells[2*i+1].density {
+					a = 2*i + 2
+				}
+			}
+			if cells[i].density > cells[a].density {
+				cells[i], cells[a] = cells[a], cells[i] //if parent < child, heapify down
+				i = a
+			} else {
+				break //if no need to swap, break loop
+			}
+		}
+	}
+	return cells
+}
+
+// Born takes a slice of Cell, birthRadius, and birthkey as inputs,
+// and returns an updated slice of Cell
+func (board GameBoard) Born(birthRadius, searchRadius float64, birthkey int) []Cell {
+	return board.born(NewGrid(board.cells, board.width, searchRadius), birthRadius, searchRadius, birthkey)
+}
+
+// born is Born with the radius queries answered by index, which is kept up to date with the newborn cells
+func (board GameBoard) born(index SpatialIndex, birthRadius, searchRadius float64, birthkey int) []Cell {
+	length := len(board.cells)
+	//for every cell in birthrate, born one cell
+	for i := birthkey; i <= length-1; i++ {
+		for _, newborn := range board.offspring(index, board.cells[i], len(board.cells), birthRadius, searchRadius) {
+			board.cells = append(board.cells, newborn)
+			index.Insert(newborn)
+		}
+	}
+	return board.cells
+}
+
+/*
+	bornParallel is born shared by workers goroutines. Every chunk of parents draws from
+	its own stream (see chunkRand) and counts the density of the candidates against the
+	cells as they were before any birth this generation, so the newborns do not depend
+	on the number of workers or the order the chunks run in.
+*/
+
+func (board GameBoard) bornParallel(index SpatialIndex, birthRadius, searchRadius float64, birthkey, workers int) []Cell {
+	length := len(board.cells)
+	seed := board.rng.Int63()
+	parents := length - birthkey
+	if parents < 0 {
+		parents = 0
+	}
+	newborns := make([][]Cell, (parents+chunkSize-1)/chunkSize)
+	forChunks(parents, workers, func(chunk, lo, hi int) {
+		local := board
+		local.rng = chunkRand(seed, chunk)
+		for i := birthkey + lo; i < birthkey+hi; i++ {
+			newborns[chunk] = append(newborns[chunk], local.offspring(index, board.cells[i], length, birthRadius, searchRadius)...)
+		}
+	})
+	for _, cells := range newborns {
+		board.cells = append(board.cells, cells...)
+	}
+	return board.cells
+}
+
+// offspring returns the cells parent gives birth to: none, one, or two where a zone promotes birth
+func (board GameBoard) offspring(index SpatialIndex, parent Cell, min int, birthRadius, searchRadius float64) []Cell {
+	//randomly generate 10 cells and choose the one with least density
+	a := make([]Cell, 10)
+	choice := 0
+	for j := 0; j <= 9; j++ {
+		a[j] = board.GenerateCell(parent.x, parent.y, birthRadius)
+		a[j].density += float64(index.Count(a[j].x, a[j].y, searchRadius))
+		if a[j].density < float64(min) {
+			choice = j
+		}
+	}
+
+	a[choice].descend(parent, board.generation)
+
+	//decide total survival rate for the new cell
+	survival := 1.0
+
+	//check maze edge
+	for z := 0; z < len(board.maze); z++ {
+		xdiff := a[choice].x - board.maze[z].x
+		ydiff := a[choice].y - board.maze[z].y
+		if xdiff > 0 && xdiff < board.maze[z].width && ydiff > 0 && ydiff < board.maze[z].height {
+			survival = 0
+			break
+		}
+	}
+
+	//check zone
+	if survival != 0 {
+		for z := 0; z < len(board.zone); z++ {
+			xdiff := a[choice].x - board.zone[z].centrex
+			ydiff := a[choice].y - board.zone[z].centrey
+			if math.Sqrt((xdiff)*(xdiff)+(ydiff)*(ydiff)) < board.zone[z].radius { //if cell generate in zone
+				survival += board.zone[z].strength
+			}
+		}
+	}
+
+	//limit the change to [0, 2]
+	if survival < 0 {
+		survival = 0
+	} else if survival > 2 {
+		survival = 2
+	}
+
+	//decide if to keep the new cell or pormote cell birth based on survival rate
+	if survival < 1 {
+		if board.rng.Float64() <= survival { //keep the cell with survival possibility
+			return []Cell{a[choice]}
+		}
+	} else if survival >= 1 {
+		if board.rng.Float64() < survival-1 { //born another cell with survival possibility
+			bonus := board.GenerateCell(parent.x, parent.y, birthRadius)
+			bonus.descend(parent, board.generation)
+			return []Cell{a[choice], bonus}
+		}
+		return []Cell{a[choice]}
+	}
+	return nil
+}
+
+//Move takes repel and attract points index and calculate each cell's movemnet in forcefield
+func (board GameBoard) Move(birthkey, deathkey int, searchRadius float64) []Cell {
+	return board.move(NewGrid(board.cells, board.width, searchRadius), birthkey, deathkey, searchRadius, 1)
+}
+
+//move is Move with the radius queries answered by index, shared by workers goroutines
+func (board GameBoard) move(index SpatialIndex, birthkey, deathkey int, searchRadius float64, workers int) []Cell {
+	newcells := make([]Cell, 0)
+	for i := 0; i <= len(board.cells)-1; i++ {
+		newcells = append(newcells, board.cells[i])
+	}
+
+	forChunks(len(board.cells), workers, func(_, lo, hi int) {
+		board.moveRange(newcells, index, lo, hi, birthkey, deathkey, searchRadius)
+	})
+	return newcells
+}
+
+//moveRange moves the cells [lo, hi) of newcells by the forces of board.cells
+func (board GameBoard) moveRange(newcells []Cell, index SpatialIndex, lo, hi, birthkey, deathkey int, searchRadius float64) {
+	var near []int
+	for i := lo; i < hi; i++ {
+		//cells from birthkey to the middle of the rest are attract points, cells before deathkey are repel points;
+		//one cell is only affected by the points in its search radius
+		near = index.Neighbours(board.cells[i].x, board.cells[i].y, searchRadius, near[:0])
+		xmove, ymove := netForce(board.cells, i, near, birthkey, (len(board.cells)+birthkey)/2, deathkey)
+
+		//limit cell in board
+		newcells[i].x += xmove
+		newcells[i].y += ymove
+		if newcells[i].x < 0.0 {
+			newcells[i].x = 0.0
+		}
+		if newcells[i].x > board.width {
+			newcells[i].x = board.width
+		}
+		if newcells[i].y < 0.0 {
+			newcells[i].y = 0.0
+		}
+		if newcells[i].y > board.width {
+			newcells[i].y = board.width
+		}
+	}
+}
+
+/*
+	netForce returns the movement of cells[i] caused by the cells in near, which must be
+	in increasing order. Cells with index from birthkey to attractEnd attract cells[i],
+	and cells before deathkey repel it, with a force of 1/distance^2 limited to 1. All the
+	attractions are added before the repulsions, in index order, as Move always has.
+*/
+
+func netForce(cells []Cell, i int, near []int, birthkey, attractEnd, deathkey int) (float64, float64) {
+	xmove := 0.0
+	ymove := 0.0
+	for _, j := range near {
+		if i != j && j >= birthkey && j <= attractEnd {
+			distance := CalculateDistance(cells[i], cells[j])
+			attractForce := 1.0 / (distance * distance)
+			if attractForce > 1.0 {
+				attractForce = 1.0
+			}
+			xratio := (cells[j].x - cells[i].x) / distance
+			yratio := (cells[j].y - cells[i].y) / distance
+			xmove += xratio * attractForce
+			ymove += yratio * attractForce
+		}
+	}
+	for _, k := range near {
+		if i != k && k < deathkey {
+			distance := CalculateDistance(cells[i], cells[k])
+			repelForce := -1.0 / (distance * distance)
+			if repelForce < -1.0 {
+				repelForce = -1.0
+			}
+			xratio := (cells[k].x - cells[i].x) / distance
+			yratio := (cells[k].y - cells[i].y) / distance
+			xmove += xratio * repelForce
+			ymove += yratio * repelForce
+		}
+	}
+	return xmove, ymove
+}
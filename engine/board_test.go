@@ -0,0 +1,192 @@
+package engine
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// fingerprint hashes the places of cells, in any order, to the first 16 hex digits of their SHA-1
+func fingerprint(cells []Cell) string {
+	places := make([]string, len(cells))
+	for i, c := range cells {
+		places[i] = fmt.Sprintf("%.12g %.12g", c.x, c.y)
+	}
+	sort.Strings(places)
+	h := sha1.New()
+	for _, place := range places {
+		fmt.Fprintln(h, place)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))[:16]
+}
+
+/*
+	baselineBoards are the boards the simulation made before workers, birth modes and
+	the other strategies were added, from seed 42 with the default inputs: their number
+	of cells and fingerprint at some generations, with the sources and the signal for
+	the source and sink model. The default serial births must still make them.
+*/
+
+var baselineBoards = []struct {
+	generation int
+	cells      int
+	print      string
+}{
+	{10, 12, "3232fa3b1e3bde15"},
+	{20, 23, "55864a9ba07186f5"},
+	{30, 36, "d602ec06359f759a"},
+}
+
+var baselineTwoClusterBoards = []struct {
+	generation int
+	sources    int
+	sinks      int
+	signal     int
+	print      string
+}{
+	{10, 45, 31, 10, "8629dee723f75a54"},
+	{20, 164, 86, 10, "1c590adfe56e64a4"},
+	{30, 436, 257, 10, "4f2347640d776a60"},
+}
+
+func TestSerialMatchesBaseline(t *testing.T) {
+	for _, index := range IndexKinds {
+		for _, workers := range []int{1, 4} {
+			cfg := DefaultConfig()
+			cfg.Index, cfg.Workers = index, workers
+			name := fmt.Sprintf("index=%s workers=%d", index, workers)
+
+			board := InitializeBoard(5, cfg.BirthRadius, cfg.Width, rand.New(NewSource(42))).AddZone(2).MakeMaze()
+			boards := UpdateBoard(board, 30, cfg.Params())
+			for _, want := range baselineBoards {
+				cells := boards[want.generation].cells
+				if len(cells) != want.cells || fingerprint(cells) != want.print {
+					t.Errorf("%s: one cluster generation %d has %d cells %s, baseline %d cells %s", name, want.generation, len(cells), fingerprint(cells), want.cells, want.print)
+				}
+			}
+
+			twoBoards := InitializeTwoClusterBoard(20, cfg.BirthRadius, cfg.Width, rand.New(NewSource(42))).UpdateBoard(30, cfg.Params())
+			for _, want := range baselineTwoClusterBoards {
+				b := twoBoards[want.generation]
+				cells := append(append([]Cell{}, b.cells[0]...), b.cells[1]...)
+				if len(b.cells[0]) != want.sources || len(b.cells[1]) != want.sinks || b.totalsignal != want.signal || fingerprint(cells) != want.print {
+					t.Errorf("%s: two cluster generation %d has %d sources, %d sinks, signal %d, %s; baseline %d, %d, %d, %s", name, want.generation,
+						len(b.cells[0]), len(b.cells[1]), b.totalsignal, fingerprint(cells), want.sources, want.sinks, want.signal, want.print)
+				}
+			}
+		}
+	}
+}
+
+// freshDensities returns the densities estimator gives a copy of cells with their densities cleared
+func freshDensities(estimator DensityEstimator, cells []Cell, width float64, p Params) []float64 {
+	cleared := append([]Cell(nil), cells...)
+	for i := range cleared {
+		cleared[i].density = 0
+	}
+	cleared = estimator.Density(cleared, width, p)
+	densities := make([]float64, len(cleared))
+	for i := range cleared {
+		densities[i] = cleared[i].density
+	}
+	return densities
+}
+
+/*
+	TestStreamedDensity checks that every board UpdateBoard returns, the first one
+	included, has the density of its own cells, as a fresh run of the estimator on
+	them gives it, and keeps it once later boards are made.
+*/
+
+func TestStreamedDensity(t *testing.T) {
+	for _, strategy := range DensityStrategies() {
+		t.Run(strategy, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.Strategy = strategy
+			p := cfg.Params()
+			board := InitializeBoard(cfg.InitialCells, cfg.BirthRadius, cfg.Width, rand.New(NewSource(cfg.Seed)))
+			for _, b := range UpdateBoard(board, 4, p) {
+				want := freshDensities(densityOf(p), b.cells, b.width, p)
+				for i, c := range b.cells {
+					if c.density != want[i] {
+						t.Fatalf("generation %d: cell %d has density %g, the estimator gives %g", b.generation, c.id, c.density, want[i])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestStreamedDensityTwoCluster(t *testing.T) {
+	for _, strategy := range DensityStrategies() {
+		t.Run(strategy, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.Strategy = strategy
+			p := cfg.Params()
+			board := InitializeTwoClusterBoard(cfg.InitialCells, cfg.BirthRadius, cfg.Width, rand.New(NewSource(cfg.Seed)))
+			for _, b := range board.UpdateBoard(4, p) {
+				for k, cells := range b.cells {
+					want := freshDensities(densityOf(p), cells, b.width, p)
+					if scorer, ok := densityOf(p).(GrowthScorer); ok {
+						for i := range want {
+							want[i] = scorer.GrowthScores([]Cell{{density: want[i]}})[0].density
+						}
+					}
+					for i, c := range cells {
+						if c.density != want[i] {
+							t.Fatalf("generation %d: cell %d of cluster %d has density %g, the estimator gives %g", b.generation, c.id, k, c.density, want[i])
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+// scoredFunc is a DensityFunc made a GrowthScorer by the scores of CountDensity
+type scoredFunc struct{ DensityFunc }
+
+func (scoredFunc) GrowthScores(cells []Cell) []Cell { return growthScores(cells) }
+
+/*
+	TestGrowthScorer registers CountDensity wrapped twice, once as a GrowthScorer and once
+	not, and checks that the source and sink model scores the densities of the first
+	as it does those of CountDensity, and uses those of the second as they are counted.
+*/
+
+func TestGrowthScorer(t *testing.T) {
+	count := DensityFunc(countEstimator{}.Density)
+	RegisterDensity("ScoredCount", scoredFunc{count})
+	RegisterDensity("PlainCount", count)
+	defer delete(densityEstimators, "ScoredCount")
+	defer delete(densityEstimators, "PlainCount")
+
+	cfg := testConfig()
+	board := InitializeTwoClusterBoard(cfg.InitialCells, cfg.BirthRadius, cfg.Width, rand.New(NewSource(cfg.Seed)))
+	densities := func(strategy string) []float64 {
+		cfg.Strategy = strategy
+		var d []float64
+		for _, cells := range board.measured(cfg.Params()).cells {
+			for _, c := range cells {
+				d = append(d, c.density)
+			}
+		}
+		return d
+	}
+	builtin, scored, plain := densities("CountDensity"), densities("ScoredCount"), densities("PlainCount")
+	if !reflect.DeepEqual(scored, builtin) {
+		t.Error("a registered GrowthScorer is scored differently from CountDensity")
+	}
+	for i := range plain {
+		if plain[i] != math.Trunc(plain[i]) {
+			t.Fatalf("density %g of an estimator that is not a GrowthScorer is not a number of neighbours", plain[i])
+		}
+	}
+	if reflect.DeepEqual(plain, builtin) {
+		t.Error("the densities of an estimator that is not a GrowthScorer were scored")
+	}
+}
@@ -0,0 +1,227 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+)
+
+// CheckpointVersion is the version of the checkpoint format written by SaveCheckpoint
+const CheckpointVersion = 7
+
+// The models a Checkpoint can hold
+const (
+	ModelOneCluster = "onecluster"
+	ModelTwoCluster = "twocluster"
+)
+
+/*
+	Checkpoint is everything needed to carry on a run from the middle: the config it was
+	started with, the board at Generation (cells, zones, maze and signal), and the state
+	of the random numbers, so that resuming gives exactly the boards the uninterrupted
+	run would have given. One cluster runs fill Cells, source and sink runs fill Sources,
+	Sinks and TotalSignal. A run that records its Lineage keeps it in Lineage, as Nodes
+	returns it, so that the lineage of the resumed run still has the cells that died
+	before the checkpoint.
+*/
+
+type Checkpoint struct {
+	Version    int              `json:"version"`
+	Model      string           `json:"model"`
+	Generation int              `json:"generation"`
+	Config     SimulationConfig `json:"config"`
+	RNG        RandState        `json:"rng"`
+	Width      float64          `json:"width"`
+	Births     int              `json:"births"`
+	Deaths     int              `json:"deaths"`
+	NextID     int              `json:"nextID"`
+
+	Cells []CellState      `json:"cells,omitempty"`
+	Zones []ZoneState      `json:"zones,omitempty"`
+	Maze  []RectangleState `json:"maze,omitempty"`
+
+	Sources     []CellState `json:"sources,omitempty"`
+	Sinks       []CellState `json:"sinks,omitempty"`
+	TotalSignal int         `json:"totalsignal,omitempty"`
+
+	Lineage []LineageNode `json:"lineage,omitempty"`
+}
+
+// CellState is a Cell in a checkpoint
+type CellState struct {
+	ID          int     `json:"id"`
+	Parent      int     `json:"parent,omitempty"`
+	Born        int     `json:"born,omitempty"`
+	Depth       int     `json:"depth,omitempty"`
+	Founder     int     `json:"founder"`
+	Age         int     `json:"age,omitempty"`
+	Type        int     `json:"type,omitempty"`
+	X           float64 `json:"x"`
+	Y           float64 `json:"y"`
+	Density     float64 `json:"density"`
+	SignalLevel int     `json:"signalLevel,omitempty"`
+}
+
+// ZoneState is a Zone in a checkpoint
+type ZoneState struct {
+	Shape    string  `json:"shape"`
+	Strength float64 `json:"strength"`
+	X        float64 `json:"x"`
+	Y        float64 `json:"y"`
+	Radius   float64 `json:"radius"`
+}
+
+// RectangleState is a wall of the maze in a checkpoint
+type RectangleState struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+/*
+	NewCheckpoint returns the checkpoint of a one cluster board run with cfg. src must be
+	the Source of the board's rng, as given to InitializeBoard by rand.New(src).
+*/
+
+func NewCheckpoint(board GameBoard, cfg SimulationConfig, src *Source) Checkpoint {
+	var c Checkpoint
+	c.Version = CheckpointVersion
+	c.Model = ModelOneCluster
+	c.Generation = board.generation
+	c.Config = cfg
+	c.RNG = src.State()
+	c.Width = board.width
+	c.Births, c.Deaths = board.births, board.deaths
+	c.NextID = board.nextID
+	c.Cells = cellStates(board.cells)
+	for _, z := range board.zone {
+		c.Zones = append(c.Zones, ZoneState{z.shape, z.strength, z.centrex, z.centrey, z.radius})
+	}
+	for _, r := range board.maze {
+		c.Maze = append(c.Maze, RectangleState{r.x, r.y, r.width, r.height})
+	}
+	return c
+}
+
+// NewTwoClusterCheckpoint returns the checkpoint of a source and sink board, like NewCheckpoint
+func NewTwoClusterCheckpoint(board TwoClusterBoard, cfg SimulationConfig, src *Source) Checkpoint {
+	var c Checkpoint
+	c.Version = CheckpointVersion
+	c.Model = ModelTwoCluster
+	c.Generation = board.generation
+	c.Config = cfg
+	c.RNG = src.State()
+	c.Width = board.width
+	c.Births, c.Deaths = board.births, board.deaths
+	c.NextID = board.nextID
+	c.Sources = cellStates(board.cells[0])
+	c.Sinks = cellStates(board.cells[1])
+	c.TotalSignal = board.totalsignal
+	return c
+}
+
+/*
+	GameBoard rebuilds the one cluster board of c, together with the Source its rng draws
+	from, so that later checkpoints of the resumed run can be made with it.
+*/
+
+func (c Checkpoint) GameBoard() (GameBoard, *Source, error) {
+	if c.Model != ModelOneCluster {
+		return GameBoard{}, nil, fmt.Errorf("checkpoint is of the %s model, not %s", c.Model, ModelOneCluster)
+	}
+	src := RestoreSource(c.RNG)
+	var board GameBoard
+	board.cells = cellsOf(c.Cells)
+	board.width = c.Width
+	board.zone = make([]Zone, 0)
+	for _, z := range c.Zones {
+		board.zone = append(board.zone, Zone{z.Shape, z.Strength, z.X, z.Y, z.Radius})
+	}
+	board.maze = make([]Rectangle, 0)
+	for _, r := range c.Maze {
+		board.maze = append(board.maze, Rectangle{r.X, r.Y, r.Width, r.Height})
+	}
+	board.rng = rand.New(src)
+	board.generation = c.Generation
+	board.births, board.deaths = c.Births, c.Deaths
+	board.nextID = c.NextID
+	return board, src, nil
+}
+
+// TwoClusterBoard rebuilds the source and sink board of c, like GameBoard
+func (c Checkpoint) TwoClusterBoard() (TwoClusterBoard, *Source, error) {
+	if c.Model != ModelTwoCluster {
+		return TwoClusterBoard{}, nil, fmt.Errorf("checkpoint is of the %s model, not %s", c.Model, ModelTwoCluster)
+	}
+	src := RestoreSource(c.RNG)
+	var board TwoClusterBoard
+	board.cells = [][]Cell{cellsOf(c.Sources), cellsOf(c.Sinks)}
+	board.width = c.Width
+	board.totalsignal = c.TotalSignal
+	board.rng = rand.New(src)
+	board.generation = c.Generation
+	board.births, board.deaths = c.Births, c.Deaths
+	board.nextID = c.NextID
+	return board, src, nil
+}
+
+// cellStates converts cells for a checkpoint
+func cellStates(cells []Cell) []CellState {
+	states := make([]CellState, len(cells))
+	for i, c := range cells {
+		states[i] = CellState{ID: c.id, Parent: c.parent, Born: c.born, Depth: c.depth, Founder: c.founder, Age: c.age, Type: c.celltype, X: c.x, Y: c.y, Density: c.density, SignalLevel: c.signalLevel}
+	}
+	return states
+}
+
+// cellsOf converts the cells of a checkpoint back
+func cellsOf(states []CellState) []Cell {
+	cells := make([]Cell, len(states))
+	for i, s := range states {
+		cells[i] = Cell{id: s.ID, parent: s.Parent, born: s.Born, depth: s.Depth, founder: s.Founder, age: s.Age, celltype: s.Type, x: s.X, y: s.Y, density: s.Density, signalLevel: s.SignalLevel}
+	}
+	return cells
+}
+
+/*
+	SaveCheckpoint writes c to filename as JSON. The file is written next to filename and
+	renamed over it, so a run stopped while saving still leaves the last checkpoint.
+*/
+
+func SaveCheckpoint(c Checkpoint, filename string) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("checkpoint of generation %d: %v", c.Generation, err)
+	}
+	tmp := filename + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filename)
+}
+
+// LoadCheckpoint reads a checkpoint written by SaveCheckpoint, refusing versions it does not know
+func LoadCheckpoint(filename string) (Checkpoint, error) {
+	var c Checkpoint
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return c, err
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("%s: not a checkpoint: %v", filename, err)
+	}
+	if c.Version != CheckpointVersion {
+		return c, fmt.Errorf("%s: checkpoint version %d, expected %d", filename, c.Version, CheckpointVersion)
+	}
+	if c.Model != ModelOneCluster && c.Model != ModelTwoCluster {
+		return c, fmt.Errorf("%s: unknown model %q", filename, c.Model)
+	}
+	// errors in the saved config point at the checkpoint, and its seed is kept
+	c.Config.origins = make(map[string]position)
+	for _, key := range ConfigKeys {
+		c.Config.origins[key.Name] = position{file: filename}
+	}
+	return c, nil
+}
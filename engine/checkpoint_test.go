@@ -0,0 +1,103 @@
+package engine
+
+import (
+	"math/rand"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const (
+	resumeGens = 12 // generations of the uninterrupted run
+	resumeAt   = 5  // generation the interrupted run is checkpointed at
+)
+
+func TestResumeOneCluster(t *testing.T) {
+	cfg := testConfig()
+	cfg.AddMaze = 1
+	cfg.DeathMode = "both"
+	p := cfg.Params()
+	start := func() (GameBoard, *Source) {
+		src := NewSource(cfg.Seed)
+		return InitializeBoard(cfg.InitialCells, cfg.BirthRadius, cfg.Width, rand.New(src)).AddZone(cfg.NumZones).MakeMaze(), src
+	}
+
+	whole, _ := start()
+	for gen := 0; gen < resumeGens; gen++ {
+		whole = whole.UpdateOneBoard(p)
+	}
+
+	board, src := start()
+	for gen := 0; gen < resumeAt; gen++ {
+		board = board.UpdateOneBoard(p)
+	}
+	checkpoint := saveAndLoad(t, NewCheckpoint(board, cfg, src))
+	board, _, err := checkpoint.GameBoard()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for gen := resumeAt; gen < resumeGens; gen++ {
+		board = board.UpdateOneBoard(p)
+	}
+
+	if board.generation != whole.generation || board.nextID != whole.nextID {
+		t.Errorf("resumed run is at generation %d with next id %d, uninterrupted at %d with %d", board.generation, board.nextID, whole.generation, whole.nextID)
+	}
+	if board.births != whole.births || board.deaths != whole.deaths {
+		t.Errorf("resumed run had %d births and %d deaths, uninterrupted %d and %d", board.births, board.deaths, whole.births, whole.deaths)
+	}
+	if !reflect.DeepEqual(cellStates(board.cells), cellStates(whole.cells)) {
+		t.Errorf("resumed run has a different board of %d cells than the uninterrupted run, with %d", len(board.cells), len(whole.cells))
+	}
+}
+
+func TestResumeTwoCluster(t *testing.T) {
+	cfg := testConfig()
+	p := cfg.Params()
+	start := func() (TwoClusterBoard, *Source) {
+		src := NewSource(cfg.Seed)
+		return InitializeTwoClusterBoard(cfg.InitialCells, cfg.BirthRadius, cfg.Width, rand.New(src)), src
+	}
+
+	whole, _ := start()
+	for gen := 0; gen < resumeGens; gen++ {
+		whole = whole.UpdateOneBoard(p)
+	}
+
+	board, src := start()
+	for gen := 0; gen < resumeAt; gen++ {
+		board = board.UpdateOneBoard(p)
+	}
+	checkpoint := saveAndLoad(t, NewTwoClusterCheckpoint(board, cfg, src))
+	board, _, err := checkpoint.TwoClusterBoard()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for gen := resumeAt; gen < resumeGens; gen++ {
+		board = board.UpdateOneBoard(p)
+	}
+
+	if board.generation != whole.generation || board.totalsignal != whole.totalsignal {
+		t.Errorf("resumed run is at generation %d with signal %d, uninterrupted at %d with %d", board.generation, board.totalsignal, whole.generation, whole.totalsignal)
+	}
+	if !reflect.DeepEqual(cellStates(board.Sources()), cellStates(whole.Sources())) {
+		t.Errorf("resumed run has different sources than the uninterrupted run")
+	}
+	if !reflect.DeepEqual(cellStates(board.Sinks()), cellStates(whole.Sinks())) {
+		t.Errorf("resumed run has different sinks than the uninterrupted run")
+	}
+}
+
+// saveAndLoad writes c to a checkpoint file and reads it back, as a resumed run would
+func saveAndLoad(t *testing.T, c Checkpoint) Checkpoint {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), "run.checkpoint.json")
+	if err := SaveCheckpoint(c, filename); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadCheckpoint(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loaded
+}
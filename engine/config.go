@@ -0,0 +1,502 @@
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+	SimulationConfig holds every parameter needed to build and run a simulation:
+	the arguments of InitializeBoard, AddZone, MakeMaze and UpdateBoard. The keys
+	used in config files are the same names the old input files used.
+*/
+
+type SimulationConfig struct {
+	InitialCells    int     `json:"initialcells"`
+	NumGens         int     `json:"numGens"`
+	SearchRadius    float64 `json:"searchRadius"`
+	BirthRadius     float64 `json:"birthRadius"`
+	DeathRadius     float64 `json:"deathRadius"`
+	BirthRate       float64 `json:"birthrate"`
+	DeathRate       float64 `json:"deathrate"`
+	Width           float64 `json:"width"`
+	NumZones        int     `json:"numZones"`
+	AddMaze         int     `json:"addmaze"`
+	Strategy        string  `json:"strategy"`
+	Index           string  `json:"index"`
+	Workers         int     `json:"workers"`
+	Kernel          string  `json:"kernel"`
+	Bandwidth       float64 `json:"bandwidth"`
+	K               int     `json:"k"`
+	DeathMode       string  `json:"deathMode"`
+	BirthMode       string  `json:"birthMode"`
+	CheckpointEvery int     `json:"checkpointEvery"`
+	Seed            int64   `json:"seed"`
+
+	// where each key was set, so that errors can point back at it
+	origins map[string]position
+}
+
+// position is a place a config key was set: a file and line, or the command line
+type position struct {
+	file string
+	line int
+}
+
+// DefaultConfig returns the configuration used for every key a config file leaves out
+func DefaultConfig() SimulationConfig {
+	var cfg SimulationConfig
+	cfg.InitialCells = 5
+	cfg.NumGens = 100
+	cfg.SearchRadius = 15.0
+	cfg.BirthRadius = 7.5
+	cfg.DeathRadius = 1.5
+	cfg.BirthRate = 0.25
+	cfg.DeathRate = 0.25
+	cfg.Width = 500.0
+	cfg.NumZones = 0
+	cfg.AddMaze = 0
+	cfg.Strategy = "CountDensity"
+	cfg.Index = "grid"
+	cfg.Workers = 1
+	cfg.Kernel = "gaussian"
+	cfg.K = 5
+	cfg.DeathMode = "crowding"
+	cfg.BirthMode = "serial"
+	cfg.origins = make(map[string]position)
+	return cfg
+}
+
+// ConfigKeys lists every config key with a short description, in the order they are documented
+var ConfigKeys = []struct{ Name, Usage string }{
+	{"initialcells", "number of cells on the initial board"},
+	{"numGens", "number of generations to simulate"},
+	{"searchRadius", "radius used to count the density around a cell"},
+	{"birthRadius", "radius around a cell where a new cell can be born"},
+	{"deathRadius", "cells closer than this compete and the denser one dies"},
+	{"birthrate", "fraction of the least dense cells that give birth"},
+	{"deathrate", "fraction of the densest cells that repel their neighbours"},
+	{"width", "width and height of the board"},
+	{"numZones", "number of random zones that inhibit or promote birth"},
+	{"addmaze", "1 to build a maze on the board"},
+	{"strategy", "density strategy: CountDensity, Voronoi or any other registered DensityEstimator"},
+	{"index", "spatial index for neighbour searches: grid, or brute to compare every pair of cells"},
+	{"workers", "goroutines sharing each generation; any number gives the same boards as any other"},
+	{"kernel", "kernel of the Kernel strategy: gaussian or epanechnikov"},
+	{"bandwidth", "bandwidth of the Kernel strategy; 0 uses searchRadius"},
+	{"k", "the KNN strategy sets the density of a cell from the distance to its k-th nearest neighbour"},
+	{"deathMode", "what kills cells: crowding (cells closer than deathRadius), senescence (a hazard growing with age) or both"},
+	{"birthMode", "how births draw their random numbers: serial (the boards of earlier versions) or chunked (births shared between workers too)"},
+	{"checkpointEvery", "save a checkpoint to resume from every this many generations; 0 never saves one"},
+	{"seed", "seed of the random numbers; the same seed and inputs repeat a run exactly"},
+}
+
+// field returns a pointer to the field stored under key, or nil for an unknown key
+func (cfg *SimulationConfig) field(key string) interface{} {
+	switch key {
+	case "initialcells":
+		return &cfg.InitialCells
+	case "numGens":
+		return &cfg.NumGens
+	case "searchRadius":
+		return &cfg.SearchRadius
+	case "birthRadius":
+		return &cfg.BirthRadius
+	case "deathRadius":
+		return &cfg.DeathRadius
+	case "birthrate":
+		return &cfg.BirthRate
+	case "deathrate":
+		return &cfg.DeathRate
+	case "width":
+		return &cfg.Width
+	case "numZones":
+		return &cfg.NumZones
+	case "addmaze":
+		return &cfg.AddMaze
+	case "strategy":
+		return &cfg.Strategy
+	case "index":
+		return &cfg.Index
+	case "workers":
+		return &cfg.Workers
+	case "kernel":
+		return &cfg.Kernel
+	case "bandwidth":
+		return &cfg.Bandwidth
+	case "k":
+		return &cfg.K
+	case "deathMode":
+		return &cfg.DeathMode
+	case "birthMode":
+		return &cfg.BirthMode
+	case "checkpointEvery":
+		return &cfg.CheckpointEvery
+	case "seed":
+		return &cfg.Seed
+	}
+	return nil
+}
+
+// setText parses a textual value into the field stored under key
+func (cfg *SimulationConfig) setText(key, value string) error {
+	switch dst := cfg.field(key).(type) {
+	case nil:
+		return fmt.Errorf("unknown key")
+	case *int:
+		if key == "addmaze" {
+			return parseSwitch(value, dst)
+		}
+		return parseInt(value, dst)
+	case *int64:
+		return parseInt64(value, dst)
+	case *float64:
+		return parseFloat(value, dst)
+	case *string:
+		if key == "strategy" {
+			value = canonicalStrategy(value)
+		}
+		if key == "index" || key == "kernel" || key == "deathMode" || key == "birthMode" {
+			value = strings.ToLower(value)
+		}
+		*dst = value
+	}
+	return nil
+}
+
+// canonicalStrategy lets strategy names be written in any case, e.g. "voronoi" for "Voronoi"
+func canonicalStrategy(name string) string {
+	for _, known := range DensityStrategies() {
+		if strings.EqualFold(name, known) {
+			return known
+		}
+	}
+	return name
+}
+
+// setJSON decodes a JSON value into the field stored under key
+func (cfg *SimulationConfig) setJSON(key string, raw json.RawMessage) error {
+	dst := cfg.field(key)
+	if dst == nil {
+		return fmt.Errorf("unknown key")
+	}
+	if key == "addmaze" {
+		var b bool
+		if json.Unmarshal(raw, &b) == nil {
+			return parseSwitch(strconv.FormatBool(b), dst.(*int))
+		}
+	}
+	if err := json.Unmarshal(raw, dst); err != nil {
+		return fmt.Errorf("cannot convert %s", raw)
+	}
+	if key == "strategy" {
+		cfg.Strategy = canonicalStrategy(cfg.Strategy)
+	}
+	if key == "index" {
+		cfg.Index = strings.ToLower(cfg.Index)
+	}
+	if key == "kernel" {
+		cfg.Kernel = strings.ToLower(cfg.Kernel)
+	}
+	if key == "deathMode" {
+		cfg.DeathMode = strings.ToLower(cfg.DeathMode)
+	}
+	if key == "birthMode" {
+		cfg.BirthMode = strings.ToLower(cfg.BirthMode)
+	}
+	return nil
+}
+
+/*
+	Override sets key to value as if it had been given on the command line, so that
+	later validation errors point at the command line instead of the config file.
+*/
+
+func (cfg *SimulationConfig) Override(key, value string) error {
+	if err := cfg.setText(key, value); err != nil {
+		return ConfigErrors{{File: "command line", Key: key, Message: err.Error()}}
+	}
+	if cfg.origins == nil {
+		cfg.origins = make(map[string]position)
+	}
+	cfg.origins[key] = position{file: "command line"}
+	return nil
+}
+
+// Params returns the update parameters of cfg
+func (cfg SimulationConfig) Params() Params {
+	var p Params
+	p.SearchRadius = cfg.SearchRadius
+	p.BirthRadius = cfg.BirthRadius
+	p.DeathRadius = cfg.DeathRadius
+	p.BirthRate = cfg.BirthRate
+	p.DeathRate = cfg.DeathRate
+	p.Strategy = cfg.Strategy
+	p.Index = cfg.Index
+	p.Workers = cfg.Workers
+	p.Kernel = cfg.Kernel
+	p.Bandwidth = cfg.Bandwidth
+	p.K = cfg.K
+	p.DeathMode = cfg.DeathMode
+	p.BirthMode = cfg.BirthMode
+	return p
+}
+
+/*
+	ResolveSeed picks a seed from the clock if none was given in a file or on the
+	command line. The seed is kept in cfg so that it is recorded with the outputs.
+*/
+
+func (cfg *SimulationConfig) ResolveSeed() {
+	if _, ok := cfg.origins["seed"]; !ok {
+		cfg.Seed = time.Now().UnixNano()
+	}
+}
+
+// SeedComment returns the text of the comment the seed of a run is recorded with in outputs that have no field for it
+func SeedComment(seed int64) string {
+	return "seed: " + strconv.FormatInt(seed, 10)
+}
+
+/*
+	LoadConfig reads a simulation config from filename and returns it with defaults
+	filled in for every missing key. Files ending in .json are read as JSON; anything
+	else is read as flat YAML, which also covers the old "key: value" input files.
+	TOML is not supported, and .toml files are refused rather than misread. Every
+	problem found while reading is returned together as ConfigErrors.
+*/
+
+func LoadConfig(filename string) (SimulationConfig, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".toml":
+		return SimulationConfig{}, ConfigErrors{{File: filename, Message: "TOML configs are not supported, use a .yaml, .txt or .json file"}}
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return SimulationConfig{}, err
+	}
+
+	if strings.EqualFold(filepath.Ext(filename), ".json") {
+		return ParseJSONConfig(filename, data)
+	}
+	return ParseTextConfig(filename, data)
+}
+
+/*
+	ParseJSONConfig decodes a JSON object on top of the defaults. Unknown, repeated and
+	badly typed keys are all reported with the line they appear on.
+*/
+
+func ParseJSONConfig(filename string, data []byte) (SimulationConfig, error) {
+	cfg := DefaultConfig()
+	var errs ConfigErrors
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	syntaxError := func(err error) (SimulationConfig, error) {
+		line := lineAt(data, decoder.InputOffset())
+		if serr, ok := err.(*json.SyntaxError); ok {
+			line = lineAt(data, serr.Offset)
+		}
+		errs = append(errs, ConfigError{File: filename, Line: line, Message: err.Error()})
+		return SimulationConfig{}, errs
+	}
+
+	if tok, err := decoder.Token(); err != nil {
+		return syntaxError(err)
+	} else if tok != json.Delim('{') {
+		errs = append(errs, ConfigError{File: filename, Line: 1, Message: "expected a JSON object"})
+		return SimulationConfig{}, errs
+	}
+
+	for decoder.More() {
+		tok, err := decoder.Token()
+		if err != nil {
+			return syntaxError(err)
+		}
+		key := tok.(string)
+		line := lineAt(data, decoder.InputOffset())
+
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return syntaxError(err)
+		}
+		errs = cfg.record(errs, filename, line, key, func() error { return cfg.setJSON(key, raw) })
+	}
+	if _, err := decoder.Token(); err != nil {
+		return syntaxError(err)
+	}
+
+	if len(errs) > 0 {
+		return SimulationConfig{}, errs
+	}
+	return cfg, nil
+}
+
+/*
+	ParseTextConfig decodes a flat YAML config on top of the defaults. Each non-empty
+	line is "key: value" with a single value, which may be quoted; "#" at the start of a
+	line or after a space starts a comment, unless it is inside quotes. The rest of YAML
+	(nested maps, lists, flow collections, block scalars, anchors and aliases) has no
+	key to go to, and is reported as unsupported rather than misread. Unknown, repeated
+	and unparsable keys are all reported with their line.
+*/
+
+func ParseTextConfig(filename string, data []byte) (SimulationConfig, error) {
+	cfg := DefaultConfig()
+	var errs ConfigErrors
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	line := 0
+	for scanner.Scan() {
+		line++
+		raw := stripComment(scanner.Text())
+		text := strings.TrimSpace(raw)
+		if text == "" || text == "---" {
+			continue
+		}
+
+		key, value, err := splitLine(raw)
+		if err != nil {
+			errs = append(errs, ConfigError{File: filename, Line: line, Key: key, Message: err.Error()})
+			continue
+		}
+		errs = cfg.record(errs, filename, line, key, func() error { return cfg.setText(key, value) })
+	}
+	if err := scanner.Err(); err != nil {
+		errs = append(errs, ConfigError{File: filename, Line: line, Message: err.Error()})
+	}
+
+	if len(errs) > 0 {
+		return SimulationConfig{}, errs
+	}
+	return cfg, nil
+}
+
+// record applies set for key read at filename:line, appending any problem to errs
+func (cfg *SimulationConfig) record(errs ConfigErrors, filename string, line int, key string, set func() error) ConfigErrors {
+	if first, ok := cfg.origins[key]; ok {
+		return append(errs, ConfigError{File: filename, Line: line, Key: key, Message: fmt.Sprintf("already set on line %d", first.line)})
+	}
+	if err := set(); err != nil {
+		return append(errs, ConfigError{File: filename, Line: line, Key: key, Message: err.Error()})
+	}
+	cfg.origins[key] = position{file: filename, line: line}
+	return errs
+}
+
+// SaveConfig writes cfg to filename as JSON, which LoadConfig can read back
+func SaveConfig(cfg SimulationConfig, filename string) error {
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, append(data, '\n'), 0644)
+}
+
+// lineAt returns the 1-based line number of the byte at offset in data
+func lineAt(data []byte, offset int64) int {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
+
+// stripComment cuts text at the first "#" that starts a YAML comment: at the start or after a space, outside quotes
+func stripComment(text string) string {
+	var quote rune
+	for i, r := range text {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#' && (i == 0 || text[i-1] == ' ' || text[i-1] == '\t'):
+			return text[:i]
+		}
+	}
+	return text
+}
+
+// splitLine splits a line of a flat YAML config into its key and unquoted value, refusing YAML it cannot hold
+func splitLine(raw string) (key, value string, err error) {
+	text := strings.TrimSpace(raw)
+	if strings.HasPrefix(text, "- ") || text == "-" {
+		return "", "", fmt.Errorf("lists are not supported, configs are flat \"key: value\" lines")
+	}
+	i := strings.Index(text, ":")
+	if i < 0 {
+		return "", "", fmt.Errorf("expected \"key: value\", got %q", text)
+	}
+	key = strings.TrimSpace(text[:i])
+	value = strings.TrimSpace(text[i+1:])
+	switch {
+	case raw[0] == ' ' || raw[0] == '\t':
+		return key, "", fmt.Errorf("nested keys are not supported, configs are flat \"key: value\" lines")
+	case value == "":
+		return key, "", fmt.Errorf("missing value (nested maps and lists are not supported)")
+	case strings.ContainsAny(value[:1], "[{"):
+		return key, "", fmt.Errorf("flow lists and maps are not supported, got %q", value)
+	case strings.ContainsAny(value[:1], "|>"):
+		return key, "", fmt.Errorf("block scalars are not supported, got %q", value)
+	case strings.ContainsAny(value[:1], "&*!"):
+		return key, "", fmt.Errorf("anchors, aliases and tags are not supported, got %q", value)
+	}
+	return key, unquote(value), nil
+}
+
+// unquote strips one pair of matching single or double quotes around a value
+func unquote(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+func parseInt(value string, dst *int) error {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("cannot convert %q to an integer", value)
+	}
+	*dst = n
+	return nil
+}
+
+func parseInt64(value string, dst *int64) error {
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return fmt.Errorf("cannot convert %q to an integer", value)
+	}
+	*dst = n
+	return nil
+}
+
+func parseFloat(value string, dst *float64) error {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fmt.Errorf("cannot convert %q to a number", value)
+	}
+	*dst = f
+	return nil
+}
+
+// parseSwitch accepts 0/1 as the old input files wrote it, or true/false
+func parseSwitch(value string, dst *int) error {
+	switch strings.ToLower(value) {
+	case "1", "true", "yes", "on":
+		*dst = 1
+	case "0", "false", "no", "off":
+		*dst = 0
+	default:
+		return fmt.Errorf("cannot convert %q to 0 or 1", value)
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTextConfig(t *testing.T) {
+	data := `# a config of the old input files
+initialcells: 12
+searchRadius: 20.5	# with a comment
+strategy: voronoi
+deathMode: "Both"
+index: 'brute'
+addmaze: yes
+seed: 99
+---
+`
+	cfg, err := ParseTextConfig("in.txt", []byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := DefaultConfig()
+	want.InitialCells = 12
+	want.SearchRadius = 20.5
+	want.Strategy = "Voronoi"
+	want.DeathMode = "both"
+	want.Index = "brute"
+	want.AddMaze = 1
+	want.Seed = 99
+	cfg.origins, want.origins = nil, nil
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("got %+v\nwant %+v", cfg, want)
+	}
+}
+
+func TestParseTextConfigDefaults(t *testing.T) {
+	cfg, err := ParseTextConfig("empty.yaml", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := DefaultConfig()
+	cfg.origins, want.origins = nil, nil
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("an empty config gives %+v, not the defaults %+v", cfg, want)
+	}
+}
+
+func TestParseTextConfigComments(t *testing.T) {
+	for _, tc := range []struct {
+		line string
+		want string
+	}{
+		{`strategy: "Count#Density" # a comment`, "Count#Density"},
+		{`strategy: 'a # b'`, "a # b"},
+		{`strategy: a#b`, "a#b"},
+		{`strategy: ab # "c"`, "ab"},
+		{"strategy: ab\t# tab before the comment", "ab"},
+	} {
+		cfg, err := ParseTextConfig("in.yaml", []byte(tc.line))
+		if err != nil {
+			t.Errorf("%s: %v", tc.line, err)
+			continue
+		}
+		if cfg.Strategy != tc.want {
+			t.Errorf("%s: strategy is %q, want %q", tc.line, cfg.Strategy, tc.want)
+		}
+	}
+}
+
+// configErrors parses data as filename and returns the ConfigErrors it gives
+func configErrors(t *testing.T, filename, data string) ConfigErrors {
+	t.Helper()
+	var err error
+	if strings.HasSuffix(filename, ".json") {
+		_, err = ParseJSONConfig(filename, []byte(data))
+	} else {
+		_, err = ParseTextConfig(filename, []byte(data))
+	}
+	errs, ok := err.(ConfigErrors)
+	if !ok {
+		t.Fatalf("%q: expected ConfigErrors, got %v", data, err)
+	}
+	return errs
+}
+
+func TestParseTextConfigUnsupported(t *testing.T) {
+	for _, tc := range []struct {
+		data    string
+		line    int
+		message string
+	}{
+		{"zones:\n  - 1\n  - 2", 1, "nested maps and lists are not supported"},
+		{"numGens: 5\nmaze:\n  walls: 3", 2, "nested maps and lists are not supported"},
+		{"numGens: 5\n  seed: 3", 2, "nested keys are not supported"},
+		{"- numGens: 5", 1, "lists are not supported"},
+		{"numZones: [1, 2]", 1, "flow lists and maps are not supported"},
+		{"numZones: {a: 1}", 1, "flow lists and maps are not supported"},
+		{"strategy: |\n  Voronoi", 1, "block scalars are not supported"},
+		{"seed: &s 4", 1, "anchors, aliases and tags are not supported"},
+		{"numGens 5", 1, `expected "key: value"`},
+	} {
+		errs := configErrors(t, "in.yaml", tc.data)
+		if errs[0].Line != tc.line || !strings.Contains(errs[0].Message, tc.message) {
+			t.Errorf("%q: got %v, want line %d: %s", tc.data, errs[0], tc.line, tc.message)
+		}
+	}
+}
+
+func TestParseTextConfigKeys(t *testing.T) {
+	errs := configErrors(t, "in.txt", "numGens: 5\nradius: 3\nnumGens: 6\nwidth: wide\n")
+	want := []ConfigError{
+		{File: "in.txt", Line: 2, Key: "radius", Message: "unknown key"},
+		{File: "in.txt", Line: 3, Key: "numGens", Message: "already set on line 1"},
+		{File: "in.txt", Line: 4, Key: "width", Message: `cannot convert "wide" to a number`},
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("got %d errors, want %d: %v", len(errs), len(want), errs)
+	}
+	for i := range want {
+		if errs[i] != want[i] {
+			t.Errorf("error %d is %v, want %v", i, errs[i], want[i])
+		}
+	}
+}
+
+func TestParseJSONConfig(t *testing.T) {
+	cfg, err := ParseJSONConfig("in.json", []byte(`{"initialcells": 7, "strategy": "knn", "addmaze": true, "width": 300}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := DefaultConfig()
+	want.InitialCells = 7
+	want.Strategy = "KNN"
+	want.AddMaze = 1
+	want.Width = 300
+	cfg.origins, want.origins = nil, nil
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("got %+v\nwant %+v", cfg, want)
+	}
+
+	errs := configErrors(t, "in.json", "{\n\"numGens\": 5,\n\"radius\": 3,\n\"numGens\": 6\n}")
+	if len(errs) != 2 || errs[0].Key != "radius" || errs[0].Line != 3 || errs[1].Key != "numGens" || errs[1].Line != 4 {
+		t.Errorf("got %v, want an unknown key on line 3 and a repeated key on line 4", errs)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		filename := filepath.Join(dir, name)
+		if err := os.WriteFile(filename, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return filename
+	}
+
+	cfg, err := LoadConfig(write("run.json", `{"numGens": 8}`))
+	if err != nil || cfg.NumGens != 8 {
+		t.Errorf("JSON config: numGens %d, error %v", cfg.NumGens, err)
+	}
+	cfg, err = LoadConfig(write("run.yaml", "numGens: 9"))
+	if err != nil || cfg.NumGens != 9 {
+		t.Errorf("YAML config: numGens %d, error %v", cfg.NumGens, err)
+	}
+	if _, err := LoadConfig(write("run.toml", "numGens = 9")); err == nil || !strings.Contains(err.Error(), "TOML configs are not supported") {
+		t.Errorf("TOML config: got %v, want it refused", err)
+	}
+}
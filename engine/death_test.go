@@ -0,0 +1,153 @@
+package engine
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestAgeCells(t *testing.T) {
+	cells := []Cell{NewCell(1, 1), NewCell(2, 2)}
+	cells[1].age = 7
+	ageCells(cells)
+	if cells[0].age != 1 || cells[1].age != 8 {
+		t.Errorf("ages %d and %d after a generation, want 1 and 8", cells[0].age, cells[1].age)
+	}
+}
+
+/*
+	TestAgeAfterUpdate checks that a generation makes every cell that lived through it
+	a generation older, and that the cells born in it start at age 0.
+*/
+
+func TestAgeAfterUpdate(t *testing.T) {
+	cfg := testConfig()
+	p := cfg.Params()
+	board := InitializeBoard(cfg.InitialCells, cfg.BirthRadius, cfg.Width, rand.New(NewSource(cfg.Seed)))
+	for gen := 0; gen < 3; gen++ {
+		board = board.UpdateOneBoard(p)
+	}
+	ages := make(map[int]int)
+	for _, c := range board.cells {
+		ages[c.id] = c.age
+	}
+	next := board.UpdateOneBoard(p)
+	if next.births == 0 {
+		t.Fatal("no cell was born, so the test shows nothing")
+	}
+	for _, c := range next.cells {
+		want, lived := ages[c.id]
+		if lived {
+			want++
+		}
+		if c.age != want {
+			t.Errorf("cell %d (born in generation %d) has age %d, want %d", c.id, c.born, c.age, want)
+		}
+	}
+}
+
+/*
+	TestSurvivalRate checks the hazard of death with age: it grows with every generation,
+	is almost 0 for a newborn and reaches certain death at 29 generations.
+*/
+
+func TestSurvivalRate(t *testing.T) {
+	if got, want := SurvivalRate(0), math.Exp(-9.8); !near(got, want) {
+		t.Errorf("SurvivalRate(0) = %g, want %g", got, want)
+	}
+	if got, want := SurvivalRate(16), math.Exp(1.1*5-9.8); !near(got, want) {
+		t.Errorf("SurvivalRate(16) = %g, want %g", got, want)
+	}
+	for age := 1; age < 40; age++ {
+		if SurvivalRate(age) <= SurvivalRate(age-1) {
+			t.Errorf("SurvivalRate(%d) = %g is not above SurvivalRate(%d) = %g", age, SurvivalRate(age), age-1, SurvivalRate(age-1))
+		}
+	}
+	if SurvivalRate(28) >= 1 || SurvivalRate(29) < 1 {
+		t.Errorf("SurvivalRate(28) = %g, SurvivalRate(29) = %g; death should first be certain at 29", SurvivalRate(28), SurvivalRate(29))
+	}
+}
+
+/*
+	TestSenescence checks that Senescence leaves its input alone, kills every cell old
+	enough for certain death, and kills cells of an age with about the probability
+	SurvivalRate gives them.
+*/
+
+func TestSenescence(t *testing.T) {
+	const n, age = 20000, 20
+	cells := make([]Cell, n)
+	for i := range cells {
+		cells[i] = NewCell(float64(i), 0)
+		cells[i].age = age
+	}
+	cells[0].age = 29
+	alive := Senescence(cells, rand.New(NewSource(3)))
+	if cells[0].age != 29 || cells[n-1].x != n-1 {
+		t.Error("Senescence changed the cells it was given")
+	}
+	if len(alive) > 0 && alive[0].age == 29 {
+		t.Error("a cell of age 29 survived")
+	}
+	died := float64(n - len(alive) - 1)
+	rate := SurvivalRate(age)
+	mean, sd := (n-1)*rate, math.Sqrt((n-1)*rate*(1-rate))
+	if math.Abs(died-mean) > 5*sd {
+		t.Errorf("%g of %d cells of age %d died, want about %.0f", died, n-1, age, mean)
+	}
+}
+
+/*
+	TestDeathModes makes a generation of a board with neither births nor moves: two
+	crowded young cells, and an old cell on its own. Crowding kills one of the pair,
+	senescence the old cell, and both of them do both.
+*/
+
+func TestDeathModes(t *testing.T) {
+	for _, tc := range []struct {
+		mode     string
+		cells    int
+		oldAlive bool
+	}{
+		{"", 2, true},
+		{"crowding", 2, true},
+		{"senescence", 2, false},
+		{"both", 1, false},
+	} {
+		old := NewCell(80, 80)
+		old.id, old.age = 3, 40
+		board := GameBoard{
+			cells:  []Cell{NewCell(10, 10), NewCell(10, 10.5), old},
+			width:  100,
+			rng:    rand.New(NewSource(1)),
+			nextID: 4,
+		}
+		board.cells[0].id, board.cells[1].id = 1, 2
+		cfg := DefaultConfig()
+		cfg.BirthRate, cfg.DeathRate, cfg.DeathMode = 0, 0, tc.mode
+		next := board.UpdateOneBoard(cfg.Params())
+
+		oldAlive := false
+		for _, c := range next.cells {
+			oldAlive = oldAlive || c.id == 3
+		}
+		if len(next.cells) != tc.cells || oldAlive != tc.oldAlive || next.deaths != 3-tc.cells {
+			t.Errorf("deathMode %q: %d cells left (%d deaths), old cell alive %v; want %d, %d, %v",
+				tc.mode, len(next.cells), next.deaths, oldAlive, tc.cells, 3-tc.cells, tc.oldAlive)
+		}
+	}
+}
+
+// TestSenescenceRun checks that no cell of a run with senescence lives to the age of certain death
+func TestSenescenceRun(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.DeathMode = "both"
+	board := InitializeBoard(5, cfg.BirthRadius, cfg.Width, rand.New(NewSource(42)))
+	for _, b := range UpdateBoard(board, 35, cfg.Params()) {
+		for _, c := range b.cells {
+			if c.age >= 29 {
+				t.Fatalf("cell %d is %d generations old in generation %d", c.id, c.age, b.generation)
+			}
+		}
+	}
+}
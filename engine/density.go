@@ -0,0 +1,109 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+/*
+	DensityEstimator measures how crowded every cell of a cluster is. Density sets the
+	density of each of cells, on a board of the given width, and returns them; it may
+	return them in another order. The higher the density, the more crowded the cell,
+	since boards give birth from their least dense cells and death and repulsion to
+	their densest. Params.Strategy names the estimator a board uses, from those added
+	with RegisterDensity.
+*/
+
+type DensityEstimator interface {
+	Density(cells []Cell, width float64, p Params) []Cell
+}
+
+/*
+	GrowthScorer is a DensityEstimator whose densities the source and sink model turns
+	into growth scores before it uses them: after Density has measured a cluster,
+	GrowthScores replaces the density of each of its cells and returns them. CountDensity
+	is one, scoring the number of neighbours by the logistic growth curve; an estimator
+	that is not one has its densities used as they are.
+*/
+
+type GrowthScorer interface {
+	DensityEstimator
+	GrowthScores(cells []Cell) []Cell
+}
+
+// DensityFunc lets an ordinary function be used as a DensityEstimator
+type DensityFunc func(cells []Cell, width float64, p Params) []Cell
+
+func (f DensityFunc) Density(cells []Cell, width float64, p Params) []Cell {
+	return f(cells, width, p)
+}
+
+// densityEstimators are the estimators Params.Strategy can name, by name
+var densityEstimators = map[string]DensityEstimator{
+	"CountDensity": countEstimator{},
+	"Voronoi":      voronoiEstimator{},
+}
+
+/*
+	RegisterDensity makes e available under name to Params.Strategy and the strategy
+	config key, which accepts the name in any case. It is meant to be called from an
+	init function, and panics if name is empty or already taken.
+*/
+
+func RegisterDensity(name string, e DensityEstimator) {
+	if name == "" || e == nil {
+		panic("engine: RegisterDensity needs a name and an estimator")
+	}
+	if _, taken := densityEstimators[name]; taken {
+		panic(fmt.Sprintf("engine: density strategy %q registered twice", name))
+	}
+	densityEstimators[name] = e
+}
+
+// DensityStrategies returns the name of every registered DensityEstimator, in order
+func DensityStrategies() []string {
+	names := make([]string, 0, len(densityEstimators))
+	for name := range densityEstimators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupDensity returns the DensityEstimator registered under name, with "" for CountDensity
+func LookupDensity(name string) (DensityEstimator, bool) {
+	if name == "" {
+		name = "CountDensity"
+	}
+	e, ok := densityEstimators[name]
+	return e, ok
+}
+
+// densityOf returns the DensityEstimator of p, and panics if p.Strategy names none
+func densityOf(p Params) DensityEstimator {
+	e, ok := LookupDensity(p.Strategy)
+	if !ok {
+		panic(fmt.Sprintf("engine: unknown density strategy %q, expected one of %s", p.Strategy, strings.Join(DensityStrategies(), ", ")))
+	}
+	return e
+}
+
+// countEstimator is CountDensity: the number of other cells within p.SearchRadius
+type countEstimator struct{}
+
+func (countEstimator) Density(cells []Cell, width float64, p Params) []Cell {
+	return countDensity(cells, p.SearchRadius, NewSpatialIndex(p.Index, cells, width, p.SearchRadius), p.Workers)
+}
+
+func (countEstimator) GrowthScores(cells []Cell) []Cell {
+	return growthScores(cells)
+}
+
+// voronoiEstimator is Voronoi: the inverse of the area of the Voronoi cell around each cell, clipped to the board
+type voronoiEstimator struct{}
+
+func (voronoiEstimator) Density(cells []Cell, width float64, p Params) []Cell {
+	board := GameBoard{cells: cells, width: width}
+	return Voronoi(&board).cells
+}
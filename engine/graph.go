@@ -0,0 +1,347 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// GraphFormats are the formats NewGraphWriter can write
+var GraphFormats = []string{"graphml", "edgelist"}
+
+/*
+	NeighbourGraph is the graph of the cells of one generation whose Voronoi cells,
+	clipped to the board, share a side. It is the dual of the Voronoi diagram, the
+	Delaunay triangulation of the cells, less the edges between cells on the edge of
+	the colony whose shared side would lie off the board. Cells at the same place share
+	their Voronoi cell, and each of them is joined to every cell of the places around
+	it, but not to the others at its own place.
+
+	Triangles has a triangle for each corner of a Voronoi cell inside the board, where
+	three cells meet; a place holding several cells is named by the first of them. Four
+	or more cells on a common circle meet at one corner, and may give overlapping
+	triangles there.
+*/
+
+type NeighbourGraph struct {
+	Generation int
+	Nodes      []GraphNode // in order of id
+	Edges      []GraphEdge // in order of Source, then Target
+	Triangles  [][3]int    // Delaunay triangles, the ids of each in order, in order
+}
+
+// GraphNode is a cell of a NeighbourGraph
+type GraphNode struct {
+	ID       int
+	X, Y     float64
+	CellType int // 0 in one cluster runs, 1 for a source and 2 for a sink
+}
+
+// GraphEdge joins two cells of a NeighbourGraph
+type GraphEdge struct {
+	Source, Target int     // ids of the cells, Source < Target
+	Length         float64 // length of the side their Voronoi cells share
+}
+
+// BoardGraph returns the NeighbourGraph of a one cluster board
+func BoardGraph(board GameBoard) NeighbourGraph {
+	return neighbourGraph(board.generation, board.cells, board.width)
+}
+
+// TwoClusterGraph returns the NeighbourGraph of a source and sink board, with sources and sinks in one diagram
+func TwoClusterGraph(board TwoClusterBoard) NeighbourGraph {
+	cells := append(append([]Cell(nil), board.cells[0]...), board.cells[1]...)
+	return neighbourGraph(board.generation, cells, board.width)
+}
+
+/*
+	neighbourGraph is the NeighbourGraph of cells, named by their ids. It panics if one
+	of them has none, as cells made with NewCell do until they are put on a board: they
+	would all be one node.
+*/
+
+func neighbourGraph(generation int, cells []Cell, width float64) NeighbourGraph {
+	g := NeighbourGraph{Generation: generation, Nodes: make([]GraphNode, len(cells)), Edges: []GraphEdge{}}
+	for i, c := range cells {
+		if c.id == 0 {
+			panic(fmt.Sprintf("engine: neighbour graph of a cell without an id at (%g, %g); put the cells on a board with NewGameBoard", c.x, c.y))
+		}
+		g.Nodes[i] = GraphNode{ID: c.id, X: c.x, Y: c.y, CellType: c.celltype}
+	}
+	sort.Slice(g.Nodes, func(i, j int) bool { return g.Nodes[i].ID < g.Nodes[j].ID })
+
+	// the length of the side each site shares with every other, measured on its own cell,
+	// and the sites meeting at each corner between two sides that are not on the border
+	sites, polygons := VoronoiPolygons(cells, width)
+	measured := make(map[[2]int]float64)
+	corners := make(map[[3]int]bool)
+	for _, site := range sites {
+		polygon := polygons[site.index]
+		n := len(polygon.points)
+		var sides []int
+		for k, t := range polygon.sides {
+			p, q := polygon.points[k], polygon.points[(k+1)%n]
+			if p == q {
+				continue
+			}
+			sides = append(sides, t)
+			if t >= 0 {
+				measured[[2]int{site.index, t}] += math.Hypot(q.x-p.x, q.y-p.y)
+			}
+		}
+		for k := range sides {
+			a, b := sides[k], sides[(k+1)%len(sides)]
+			if a >= 0 && b >= 0 && a != b {
+				corner := [3]int{site.index, a, b}
+				sort.Ints(corner[:])
+				corners[corner] = true
+			}
+		}
+	}
+	lengths := sharedSides(measured)
+
+	for pair, length := range lengths {
+		for _, i := range sites[pair[0]].cells {
+			for _, j := range sites[pair[1]].cells {
+				a, b := cells[i].id, cells[j].id
+				if a > b {
+					a, b = b, a
+				}
+				g.Edges = append(g.Edges, GraphEdge{Source: a, Target: b, Length: length})
+			}
+		}
+	}
+	sort.Slice(g.Edges, func(i, j int) bool {
+		if g.Edges[i].Source != g.Edges[j].Source {
+			return g.Edges[i].Source < g.Edges[j].Source
+		}
+		return g.Edges[i].Target < g.Edges[j].Target
+	})
+
+	g.Triangles = make([][3]int, 0, len(corners))
+	for corner := range corners {
+		var triangle [3]int
+		for k, s := range corner {
+			triangle[k] = cells[sites[s].cells[0]].id
+		}
+		sort.Ints(triangle[:])
+		g.Triangles = append(g.Triangles, triangle)
+	}
+	sort.Slice(g.Triangles, func(i, j int) bool {
+		a, b := g.Triangles[i], g.Triangles[j]
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		if a[1] != b[1] {
+			return a[1] < b[1]
+		}
+		return a[2] < b[2]
+	})
+	return g
+}
+
+/*
+	sharedSides returns the length of the side shared by each pair of sites s < t, from
+	the lengths measured on the cell of each site of a pair, keyed by the site first.
+	The side is measured on the cell of s, the lower site index, whatever order the
+	cells were walked in; it is measured on the cell of t only when the cell of s has
+	lost it, which the clipping can do to a side of almost no length.
+*/
+
+func sharedSides(measured map[[2]int]float64) map[[2]int]float64 {
+	lengths := make(map[[2]int]float64, len(measured)/2)
+	for pair, length := range measured {
+		s, t := pair[0], pair[1]
+		if s < t {
+			lengths[pair] = length
+		} else if _, ok := measured[[2]int{t, s}]; !ok {
+			lengths[[2]int{t, s}] = length
+		}
+	}
+	return lengths
+}
+
+// Adjacency returns the ids of the neighbours of every cell, by id, each list in order
+func (g NeighbourGraph) Adjacency() map[int][]int {
+	adjacency := make(map[int][]int, len(g.Nodes))
+	for _, node := range g.Nodes {
+		adjacency[node.ID] = []int{}
+	}
+	for _, e := range g.Edges {
+		adjacency[e.Source] = append(adjacency[e.Source], e.Target)
+		adjacency[e.Target] = append(adjacency[e.Target], e.Source)
+	}
+	for _, neighbours := range adjacency {
+		sort.Ints(neighbours)
+	}
+	return adjacency
+}
+
+/*
+	DegreeDistribution returns the number of cells with each number of neighbours, from
+	0 up to the most any cell has. Away from the border of the board, the number of
+	neighbours of a cell is the number of sides of its polygon.
+*/
+
+func (g NeighbourGraph) DegreeDistribution() []int {
+	degrees := make(map[int]int, len(g.Nodes))
+	for _, e := range g.Edges {
+		degrees[e.Source]++
+		degrees[e.Target]++
+	}
+	distribution := []int{}
+	for _, node := range g.Nodes {
+		d := degrees[node.ID]
+		for len(distribution) <= d {
+			distribution = append(distribution, 0)
+		}
+		distribution[d]++
+	}
+	return distribution
+}
+
+/*
+	GraphWriter writes the NeighbourGraph of every generation of a run. Close finishes
+	the file, and is to be called once after the last graph.
+*/
+
+type GraphWriter interface {
+	Write(g NeighbourGraph) error
+	Close() error
+}
+
+// NewGraphWriter returns a GraphWriter writing the graphs of the run of seed to w in format, one of GraphFormats
+func NewGraphWriter(w io.Writer, format string, seed int64) (GraphWriter, error) {
+	switch format {
+	case "graphml":
+		return newGraphML(w, seed), nil
+	case "edgelist":
+		return newEdgeList(w, seed), nil
+	}
+	return nil, fmt.Errorf("unknown graph format %q", format)
+}
+
+/*
+	graphML writes one GraphML document holding an undirected graph per generation,
+	with id "gN" for generation N. Node ids are made unique in the document as "gNcID";
+	the cell's own id, place and type, and the length of each edge are kept as data, and
+	the seed of the run as data of each graph.
+*/
+
+const graphMLHeader = `<?xml version="1.0" encoding="UTF-8"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns">
+  <key id="id" for="node" attr.name="id" attr.type="int"/>
+  <key id="x" for="node" attr.name="x" attr.type="double"/>
+  <key id="y" for="node" attr.name="y" attr.type="double"/>
+  <key id="celltype" for="node" attr.name="celltype" attr.type="int"/>
+  <key id="length" for="edge" attr.name="length" attr.type="double"/>
+  <key id="seed" for="graph" attr.name="seed" attr.type="long"/>
+`
+
+type graphML struct {
+	w    io.Writer
+	seed int64
+	err  error
+}
+
+func newGraphML(w io.Writer, seed int64) *graphML {
+	g := &graphML{w: w, seed: seed}
+	_, g.err = io.WriteString(w, graphMLHeader)
+	return g
+}
+
+func (g *graphML) Write(graph NeighbourGraph) error {
+	if g.err != nil {
+		return g.err
+	}
+	f := func(v float64) string { return strconv.FormatFloat(v, 'g', -1, 64) }
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "  <graph id=\"g%d\" edgedefault=\"undirected\">\n", graph.Generation)
+	fmt.Fprintf(&b, "    <data key=\"seed\">%d</data>\n", g.seed)
+	for _, n := range graph.Nodes {
+		fmt.Fprintf(&b, "    <node id=\"g%dc%d\"><data key=\"id\">%d</data><data key=\"x\">%s</data><data key=\"y\">%s</data><data key=\"celltype\">%d</data></node>\n",
+			graph.Generation, n.ID, n.ID, f(n.X), f(n.Y), n.CellType)
+	}
+	for _, e := range graph.Edges {
+		fmt.Fprintf(&b, "    <edge source=\"g%dc%d\" target=\"g%dc%d\"><data key=\"length\">%s</data></edge>\n",
+			graph.Generation, e.Source, graph.Generation, e.Target, f(e.Length))
+	}
+	b.WriteString("  </graph>\n")
+	_, g.err = g.w.Write(b.Bytes())
+	return g.err
+}
+
+func (g *graphML) Close() error {
+	if g.err != nil {
+		return g.err
+	}
+	_, g.err = io.WriteString(g.w, "</graphml>\n")
+	return g.err
+}
+
+// edgeList writes one CSV row per edge per generation, under a comment row "# seed: N" and the header generation,source,target,length
+type edgeList struct {
+	w *csv.Writer
+}
+
+func newEdgeList(w io.Writer, seed int64) *edgeList {
+	l := &edgeList{csv.NewWriter(w)}
+	l.w.Write([]string{"# " + SeedComment(seed)})
+	l.w.Write([]string{"generation", "source", "target", "length"})
+	return l
+}
+
+func (l *edgeList) Write(graph NeighbourGraph) error {
+	gen := strconv.Itoa(graph.Generation)
+	for _, e := range graph.Edges {
+		row := []string{gen, strconv.Itoa(e.Source), strconv.Itoa(e.Target), strconv.FormatFloat(e.Length, 'g', -1, 64)}
+		if err := l.w.Write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *edgeList) Close() error {
+	l.w.Flush()
+	return l.w.Error()
+}
+
+/*
+	DegreeWriter writes the DegreeDistribution of the NeighbourGraph of every
+	generation as CSV, one row per generation and number of neighbours, under a comment
+	row "# seed: N" and the header generation,degree,cells.
+*/
+
+type DegreeWriter struct {
+	w *csv.Writer
+}
+
+// NewDegreeWriter returns a DegreeWriter writing the run of seed to w
+func NewDegreeWriter(w io.Writer, seed int64) *DegreeWriter {
+	dw := &DegreeWriter{csv.NewWriter(w)}
+	dw.w.Write([]string{"# " + SeedComment(seed)})
+	dw.w.Write([]string{"generation", "degree", "cells"})
+	return dw
+}
+
+// Write writes the rows of g
+func (dw *DegreeWriter) Write(g NeighbourGraph) error {
+	gen := strconv.Itoa(g.Generation)
+	for degree, count := range g.DegreeDistribution() {
+		if err := dw.w.Write([]string{gen, strconv.Itoa(degree), strconv.Itoa(count)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush writes any buffered rows, and returns the first error met while writing
+func (dw *DegreeWriter) Flush() error {
+	dw.w.Flush()
+	return dw.w.Error()
+}
@@ -0,0 +1,237 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"math"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// latticeBoard has four cells on a 2x2 lattice of a board 100 wide, with ids 1 to 4 from the bottom left
+func latticeBoard() GameBoard {
+	board := NewGameBoard([]Cell{NewCell(25, 25), NewCell(75, 25), NewCell(25, 75), NewCell(75, 75)}, 100, rand.New(NewSource(1)))
+	board.generation = 3
+	return board
+}
+
+/*
+	TestBoardGraphLattice checks the graph of a 2x2 lattice: each cell is joined to the
+	two next to it by a side of 50, and not to the one across the diagonal.
+*/
+
+func TestBoardGraphLattice(t *testing.T) {
+	g := BoardGraph(latticeBoard())
+	if g.Generation != 3 || len(g.Nodes) != 4 || g.Nodes[0] != (GraphNode{ID: 1, X: 25, Y: 25}) {
+		t.Errorf("generation %d, nodes %v", g.Generation, g.Nodes)
+	}
+	want := []GraphEdge{{1, 2, 50}, {1, 3, 50}, {2, 4, 50}, {3, 4, 50}}
+	if len(g.Edges) != len(want) {
+		t.Fatalf("edges %v, want %v", g.Edges, want)
+	}
+	for i, e := range g.Edges {
+		if e.Source != want[i].Source || e.Target != want[i].Target || !near(e.Length, want[i].Length) {
+			t.Errorf("edge %d is %v, want %v", i, e, want[i])
+		}
+	}
+	if got := g.Adjacency(); !reflect.DeepEqual(got, map[int][]int{1: {2, 3}, 2: {1, 4}, 3: {1, 4}, 4: {2, 3}}) {
+		t.Errorf("adjacency %v", got)
+	}
+	if got := g.DegreeDistribution(); !reflect.DeepEqual(got, []int{0, 0, 4}) {
+		t.Errorf("degree distribution %v, want [0 0 4]", got)
+	}
+}
+
+// TestBoardGraphTriangle checks the graph of three cells, which meet at one corner inside the board
+func TestBoardGraphTriangle(t *testing.T) {
+	board := NewGameBoard([]Cell{NewCell(30, 30), NewCell(70, 30), NewCell(50, 70)}, 100, rand.New(NewSource(1)))
+	g := BoardGraph(board)
+	if len(g.Edges) != 3 || !reflect.DeepEqual(g.Triangles, [][3]int{{1, 2, 3}}) {
+		t.Errorf("edges %v, triangles %v; want 3 edges and the triangle [1 2 3]", g.Edges, g.Triangles)
+	}
+	if got := g.DegreeDistribution(); !reflect.DeepEqual(got, []int{0, 0, 3}) {
+		t.Errorf("degree distribution %v, want [0 0 3]", got)
+	}
+}
+
+// TestBoardGraphSamePlace checks that cells at the same place are each joined to the cells around, and not to each other
+func TestBoardGraphSamePlace(t *testing.T) {
+	board := NewGameBoard([]Cell{NewCell(25, 50), NewCell(75, 50), NewCell(75, 50)}, 100, rand.New(NewSource(1)))
+	g := BoardGraph(board)
+	if got := g.Adjacency(); !reflect.DeepEqual(got, map[int][]int{1: {2, 3}, 2: {1}, 3: {1}}) {
+		t.Errorf("adjacency %v, want 1 joined to 2 and 3", got)
+	}
+	for _, e := range g.Edges {
+		if !near(e.Length, 100) {
+			t.Errorf("edge %v, want a side of 100", e)
+		}
+	}
+}
+
+/*
+	TestBoardGraphSides checks on a random board that the graph joins the cells whose
+	polygons share a side, and that the length of each edge is the length of that side
+	as measured on both polygons, which must agree.
+*/
+
+func TestBoardGraphSides(t *testing.T) {
+	rng := rand.New(NewSource(9))
+	cells := make([]Cell, 80)
+	for i := range cells {
+		cells[i] = NewCell(rng.Float64()*100, rng.Float64()*100)
+	}
+	board := NewGameBoard(cells, 100, rng)
+	polygons := Polygons(board.Cells(), board.Width())
+	side := func(i, id int) float64 {
+		p, length := polygons[i], 0.0
+		for k := 0; k < p.Len(); k++ {
+			if p.Side(k) == id {
+				ax, ay := p.Vertex(k)
+				bx, by := p.Vertex((k + 1) % p.Len())
+				length += math.Hypot(bx-ax, by-ay)
+			}
+		}
+		return length
+	}
+
+	g := BoardGraph(board)
+	joined := 0
+	for i, c := range board.Cells() {
+		joined += len(polygons[i].Neighbours())
+		if got := g.Adjacency()[c.ID()]; len(got) != len(polygons[i].Neighbours()) {
+			t.Errorf("cell %d has %d neighbours in the graph and %d in its polygon", c.ID(), len(got), len(polygons[i].Neighbours()))
+		}
+	}
+	if joined != 2*len(g.Edges) {
+		t.Errorf("%d edges, but the polygons share %d sides", len(g.Edges), joined/2)
+	}
+	for _, e := range g.Edges {
+		// ids are the index of the cell plus one on a new board
+		a, b := side(e.Source-1, e.Target), side(e.Target-1, e.Source)
+		if !near(e.Length, a) || math.Abs(a-b) > 1e-9 {
+			t.Errorf("edge %d-%d has length %g, the sides on the polygons %g and %g", e.Source, e.Target, e.Length, a, b)
+		}
+	}
+}
+
+func TestSharedSides(t *testing.T) {
+	measured := map[[2]int]float64{{0, 1}: 2, {1, 0}: 2.5, {2, 1}: 3, {1, 2}: 4, {3, 0}: 1}
+	want := map[[2]int]float64{{0, 1}: 2, {1, 2}: 4, {0, 3}: 1}
+	if got := sharedSides(measured); !reflect.DeepEqual(got, want) {
+		t.Errorf("shared sides %v, want %v, each from the lower site", got, want)
+	}
+}
+
+func TestBoardGraphUnnumbered(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("no panic for the graph of cells without ids")
+		}
+	}()
+	BoardGraph(GameBoard{cells: []Cell{NewCell(25, 50), NewCell(75, 50)}, width: 100})
+}
+
+// writeGraphs writes the graph of the lattice board as generations 3 and 4 in format, with seed 5
+func writeGraphs(t *testing.T, format string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewGraphWriter(&buf, format, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := BoardGraph(latticeBoard())
+	for _, gen := range []int{3, 4} {
+		g.Generation = gen
+		if err := w.Write(g); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestGraphML(t *testing.T) {
+	type data struct {
+		Key   string `xml:"key,attr"`
+		Value string `xml:",chardata"`
+	}
+	var doc struct {
+		Keys []struct {
+			ID string `xml:"id,attr"`
+		} `xml:"key"`
+		Graphs []struct {
+			ID    string `xml:"id,attr"`
+			Data  []data `xml:"data"`
+			Nodes []struct {
+				ID   string `xml:"id,attr"`
+				Data []data `xml:"data"`
+			} `xml:"node"`
+			Edges []struct {
+				Source string `xml:"source,attr"`
+				Target string `xml:"target,attr"`
+				Data   []data `xml:"data"`
+			} `xml:"edge"`
+		} `xml:"graph"`
+	}
+	if err := xml.Unmarshal([]byte(writeGraphs(t, "graphml")), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Keys) != 6 || len(doc.Graphs) != 2 {
+		t.Fatalf("%d keys and %d graphs, want 6 and 2", len(doc.Keys), len(doc.Graphs))
+	}
+	g := doc.Graphs[1]
+	if g.ID != "g4" || len(g.Data) != 1 || g.Data[0] != (data{"seed", "5"}) {
+		t.Errorf("graph %s with data %v, want g4 with the seed 5", g.ID, g.Data)
+	}
+	if len(g.Nodes) != 4 || g.Nodes[1].ID != "g4c2" || !reflect.DeepEqual(g.Nodes[1].Data, []data{{"id", "2"}, {"x", "75"}, {"y", "25"}, {"celltype", "0"}}) {
+		t.Errorf("nodes %v", g.Nodes)
+	}
+	if len(g.Edges) != 4 || g.Edges[0].Source != "g4c1" || g.Edges[0].Target != "g4c2" || g.Edges[0].Data[0].Key != "length" {
+		t.Errorf("edges %v", g.Edges)
+	}
+}
+
+func TestEdgeList(t *testing.T) {
+	out := writeGraphs(t, "edgelist")
+	if !strings.HasPrefix(out, "# seed: 5\n") {
+		t.Errorf("edge list does not start with the seed:\n%s", out)
+	}
+	r := csv.NewReader(strings.NewReader(out))
+	r.Comment = '#'
+	records, err := r.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 9 || !reflect.DeepEqual(records[0], []string{"generation", "source", "target", "length"}) {
+		t.Fatalf("records %v, want a header and 4 edges for each of 2 generations", records)
+	}
+	if !reflect.DeepEqual(records[1][:3], []string{"3", "1", "2"}) || !reflect.DeepEqual(records[8][:3], []string{"4", "3", "4"}) {
+		t.Errorf("first edge %v and last %v", records[1], records[8])
+	}
+}
+
+func TestGraphFormats(t *testing.T) {
+	if _, err := NewGraphWriter(&bytes.Buffer{}, "dot", 1); err == nil {
+		t.Error("no error for an unknown graph format")
+	}
+}
+
+func TestDegreeWriter(t *testing.T) {
+	var buf bytes.Buffer
+	dw := NewDegreeWriter(&buf, 5)
+	if err := dw.Write(BoardGraph(latticeBoard())); err != nil {
+		t.Fatal(err)
+	}
+	if err := dw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	want := "# seed: 5\ngeneration,degree,cells\n3,0,0\n3,1,0\n3,2,4\n"
+	if buf.String() != want {
+		t.Errorf("degrees\n%s\nwant\n%s", buf.String(), want)
+	}
+}
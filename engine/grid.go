@@ -0,0 +1,173 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+/*
+	SpatialIndex answers radius queries over a slice of cells, so that CountDensity,
+	Born, Move and Death do not have to measure the distance to every other cell.
+	Cells are identified by their index in the slice the index was built from, and
+	a cell counts as within radius r when CalculateDistance is smaller than r, exactly
+	as in the loops the index replaces.
+*/
+
+type SpatialIndex interface {
+	// Insert adds c under the next index, as if it had been appended to the cells
+	Insert(c Cell)
+	// Count returns how many cells are within r of (x, y)
+	Count(x, y, r float64) int
+	// Neighbours appends the index of every cell within r of (x, y) to dst, in increasing order
+	Neighbours(x, y, r float64, dst []int) []int
+}
+
+// IndexKinds are the kinds of SpatialIndex that Params.Index can name
+var IndexKinds = []string{"grid", "brute"}
+
+// knownIndex reports whether kind is one of IndexKinds
+func knownIndex(kind string) bool {
+	for _, known := range IndexKinds {
+		if kind == known {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+	NewSpatialIndex builds the SpatialIndex of the given kind over cells on a board of
+	the given width. "grid" (or "") buckets cells into a uniform grid with buckets of
+	side bucketSize, usually the searchRadius; "brute" checks every cell on every
+	query and is kept as the reference the grid must agree with.
+*/
+
+func NewSpatialIndex(kind string, cells []Cell, width, bucketSize float64) SpatialIndex {
+	switch strings.ToLower(kind) {
+	case "", "grid":
+		return NewGrid(cells, width, bucketSize)
+	case "brute":
+		return &BruteIndex{append([]Cell(nil), cells...)}
+	}
+	panic(fmt.Sprintf("unknown spatial index %q", kind))
+}
+
+// maxGridSide caps the number of buckets along each side of a Grid
+const maxGridSide = 1024
+
+// Grid is a SpatialIndex that buckets cells into a uniform grid over the board
+type Grid struct {
+	size    float64 // side of a bucket
+	side    int     // number of buckets along each side
+	buckets [][]int // indexes of the cells in each bucket, in increasing order
+	xs, ys  []float64
+}
+
+/*
+	NewGrid builds a Grid over cells with buckets of side bucketSize, so that a query
+	with a radius of bucketSize only looks at the 3x3 buckets around it. Buckets are
+	made larger when the board would need more than maxGridSide of them per side.
+*/
+
+func NewGrid(cells []Cell, width, bucketSize float64) *Grid {
+	var g Grid
+	g.size = bucketSize
+	if g.size <= 0 || width/g.size > maxGridSide {
+		g.size = width / maxGridSide
+	}
+	if g.size <= 0 {
+		g.size = 1
+	}
+	g.side = int(width/g.size) + 1
+	g.buckets = make([][]int, g.side*g.side)
+	g.xs = make([]float64, 0, len(cells))
+	g.ys = make([]float64, 0, len(cells))
+	for i := range cells {
+		g.Insert(cells[i])
+	}
+	return &g
+}
+
+// bucket returns the column or row of the bucket holding v, clamped to the grid
+func (g *Grid) bucket(v float64) int {
+	b := int(math.Floor(v / g.size))
+	if b < 0 {
+		return 0
+	}
+	if b >= g.side {
+		return g.side - 1
+	}
+	return b
+}
+
+func (g *Grid) Insert(c Cell) {
+	i := len(g.xs)
+	g.xs = append(g.xs, c.x)
+	g.ys = append(g.ys, c.y)
+	b := g.bucket(c.y)*g.side + g.bucket(c.x)
+	g.buckets[b] = append(g.buckets[b], i)
+}
+
+// visit calls fn with the index of every cell within r of (x, y), bucket by bucket
+func (g *Grid) visit(x, y, r float64, fn func(i int)) {
+	col0, col1 := g.bucket(x-r), g.bucket(x+r)
+	row0, row1 := g.bucket(y-r), g.bucket(y+r)
+	for row := row0; row <= row1; row++ {
+		for col := col0; col <= col1; col++ {
+			for _, i := range g.buckets[row*g.side+col] {
+				dx := x - g.xs[i]
+				dy := y - g.ys[i]
+				if math.Sqrt(dx*dx+dy*dy) < r {
+					fn(i)
+				}
+			}
+		}
+	}
+}
+
+func (g *Grid) Count(x, y, r float64) int {
+	n := 0
+	g.visit(x, y, r, func(int) { n++ })
+	return n
+}
+
+func (g *Grid) Neighbours(x, y, r float64, dst []int) []int {
+	start := len(dst)
+	g.visit(x, y, r, func(i int) { dst = append(dst, i) })
+	sort.Ints(dst[start:])
+	return dst
+}
+
+// BruteIndex is a SpatialIndex that measures the distance to every cell on every query
+type BruteIndex struct {
+	cells []Cell
+}
+
+func (b *BruteIndex) Insert(c Cell) {
+	b.cells = append(b.cells, c)
+}
+
+func (b *BruteIndex) Count(x, y, r float64) int {
+	n := 0
+	for i := range b.cells {
+		dx := x - b.cells[i].x
+		dy := y - b.cells[i].y
+		if math.Sqrt(dx*dx+dy*dy) < r {
+			n++
+		}
+	}
+	return n
+}
+
+func (b *BruteIndex) Neighbours(x, y, r float64, dst []int) []int {
+	for i := range b.cells {
+		dx := x - b.cells[i].x
+		dy := y - b.cells[i].y
+		if math.Sqrt(dx*dx+dy*dy) < r {
+			dst = append(dst, i)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,99 @@
+package engine
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// queryBoth asks grid and brute for the neighbours and count of (x, y) within r, and reports any difference
+func queryBoth(t *testing.T, grid *Grid, brute *BruteIndex, x, y, r float64) {
+	t.Helper()
+	want := brute.Neighbours(x, y, r, nil)
+	if got := grid.Neighbours(x, y, r, nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("neighbours of (%g, %g) within %g: grid %v, brute %v", x, y, r, got, want)
+	}
+	if got := grid.Count(x, y, r); got != len(want) {
+		t.Errorf("count of (%g, %g) within %g: grid %d, brute %d", x, y, r, got, len(want))
+	}
+}
+
+func TestGridMatchesBrute(t *testing.T) {
+	const width = 500
+	rng := rand.New(rand.NewSource(1))
+	for _, tc := range []struct {
+		name   string
+		cells  int
+		radius float64
+	}{
+		{"sparse", 200, 15},
+		{"dense", 5000, 15},
+		{"radius larger than a bucket", 2000, 40},
+		{"radius smaller than a bucket", 2000, 3},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cells := make([]Cell, tc.cells)
+			for i := range cells {
+				cells[i] = NewCell(rng.Float64()*width, rng.Float64()*width)
+			}
+			grid := NewGrid(cells[:tc.cells/2], width, 15)
+			brute := NewSpatialIndex("brute", cells[:tc.cells/2], width, 15).(*BruteIndex)
+			for _, c := range cells[tc.cells/2:] {
+				grid.Insert(c)
+				brute.Insert(c)
+			}
+			for _, c := range cells[:100] {
+				queryBoth(t, grid, brute, c.x, c.y, tc.radius)
+			}
+			for i := 0; i < 100; i++ {
+				// queries off the board, as cells near the border make
+				queryBoth(t, grid, brute, rng.Float64()*(width+100)-50, rng.Float64()*(width+100)-50, tc.radius)
+			}
+		})
+	}
+}
+
+/*
+	TestGridRadiusBoundary places cells at exactly the radius from the query, on the
+	edges of buckets, where a cell counts only if it is strictly closer than the radius,
+	and just inside and outside it.
+*/
+
+func TestGridRadiusBoundary(t *testing.T) {
+	const width, r = 500.0, 15.0
+	x, y := 150.0, 150.0 // a corner of the buckets of a grid with buckets of side r
+	var cells []Cell
+	for _, d := range []float64{r, r - 1e-9, r + 1e-9} {
+		cells = append(cells,
+			NewCell(x+d, y), NewCell(x-d, y), NewCell(x, y+d), NewCell(x, y-d),
+			NewCell(x+d*0.6, y+d*0.8), NewCell(x-d*0.8, y-d*0.6),
+		)
+	}
+	cells = append(cells, NewCell(x, y), NewCell(0, 0), NewCell(width, width))
+
+	grid := NewGrid(cells, width, r)
+	brute := NewSpatialIndex("brute", cells, width, r).(*BruteIndex)
+	queryBoth(t, grid, brute, x, y, r)
+	queryBoth(t, grid, brute, 0, 0, r)
+	queryBoth(t, grid, brute, width, width, r)
+	for i := range cells {
+		queryBoth(t, grid, brute, cells[i].x, cells[i].y, r)
+	}
+	if got := grid.Count(x, y, r); got == len(cells) {
+		t.Errorf("every cell counted within %g, including those at exactly %g", r, r)
+	}
+}
+
+func BenchmarkUpdateOneBoard(b *testing.B) {
+	cfg := DefaultConfig()
+	board := InitializeBoard(10000, cfg.Width/2, cfg.Width, rand.New(NewSource(1)))
+	for _, index := range IndexKinds {
+		b.Run("index="+index, func(b *testing.B) {
+			p := cfg.Params()
+			p.Index = index
+			for i := 0; i < b.N; i++ {
+				board.UpdateOneBoard(p)
+			}
+		})
+	}
+}
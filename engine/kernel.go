@@ -0,0 +1,75 @@
+package engine
+
+import "math"
+
+// KernelKinds are the kernels Params.Kernel can name
+var KernelKinds = []string{"gaussian", "epanechnikov"}
+
+// knownKernel reports whether kind is one of KernelKinds
+func knownKernel(kind string) bool {
+	for _, known := range KernelKinds {
+		if kind == known {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	RegisterDensity("Kernel", kernelEstimator{})
+}
+
+/*
+	kernelEstimator is the Kernel strategy: the density of a cell is the sum over the
+	other cells of a kernel of their distance, so that it changes smoothly as cells move
+	instead of jumping when they cross searchRadius. Both kernels weigh a cell on top of
+	another as 1:
+
+		gaussian      exp(-(d/h)^2/2), cut off at 3h where it is about 0.011
+		epanechnikov  1-(d/h)^2 for d < h, and 0 further away
+
+	where h is p.Bandwidth, or p.SearchRadius when it is 0. The cells within the cutoff
+	are found with the spatial index named by p.Index.
+*/
+
+type kernelEstimator struct{}
+
+func (kernelEstimator) Density(cells []Cell, width float64, p Params) []Cell {
+	h := p.Bandwidth
+	if h <= 0 {
+		h = p.SearchRadius
+	}
+	weight, cutoff := gaussianKernel, 3*h
+	if p.Kernel == "epanechnikov" {
+		weight, cutoff = epanechnikovKernel, h
+	}
+
+	index := NewSpatialIndex(p.Index, cells, width, cutoff)
+	forChunks(len(cells), p.Workers, func(_, lo, hi int) {
+		var near []int
+		for i := lo; i < hi; i++ {
+			near = index.Neighbours(cells[i].x, cells[i].y, cutoff, near[:0])
+			cells[i].density = 0
+			for _, j := range near {
+				if j != i {
+					dx, dy := cells[i].x-cells[j].x, cells[i].y-cells[j].y
+					cells[i].density += weight(math.Sqrt(dx*dx+dy*dy) / h)
+				}
+			}
+		}
+	})
+	return cells
+}
+
+// gaussianKernel is the Gaussian kernel at u bandwidths from its centre
+func gaussianKernel(u float64) float64 {
+	return math.Exp(-u * u / 2)
+}
+
+// epanechnikovKernel is the Epanechnikov kernel at u bandwidths from its centre
+func epanechnikovKernel(u float64) float64 {
+	if u >= 1 {
+		return 0
+	}
+	return 1 - u*u
+}
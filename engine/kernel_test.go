@@ -0,0 +1,109 @@
+package engine
+
+import (
+	"math"
+	"testing"
+)
+
+func TestKernels(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		weight func(float64) float64
+		u      float64
+		want   float64
+	}{
+		{"gaussian", gaussianKernel, 0, 1},
+		{"gaussian", gaussianKernel, 1, math.Exp(-0.5)},
+		{"gaussian", gaussianKernel, 3, math.Exp(-4.5)},
+		{"epanechnikov", epanechnikovKernel, 0, 1},
+		{"epanechnikov", epanechnikovKernel, 0.5, 0.75},
+		{"epanechnikov", epanechnikovKernel, 1, 0},
+		{"epanechnikov", epanechnikovKernel, 2, 0},
+	} {
+		if got := tc.weight(tc.u); !near(got, tc.want) {
+			t.Errorf("%s kernel at %g bandwidths = %g, want %g", tc.name, tc.u, got, tc.want)
+		}
+	}
+}
+
+// kernelDensities returns the densities the Kernel strategy gives cells on a board 100 wide
+func kernelDensities(cells []Cell, p Params) []float64 {
+	cells = kernelEstimator{}.Density(append([]Cell(nil), cells...), 100, p)
+	densities := make([]float64, len(cells))
+	for i, c := range cells {
+		densities[i] = c.density
+	}
+	return densities
+}
+
+/*
+	TestKernelDensity places three cells 3, 4 and 5 apart, and a fourth far from them,
+	and checks their densities with each kernel and index: the sum of the weights of
+	the other cells, none of them beyond the cutoff.
+*/
+
+func TestKernelDensity(t *testing.T) {
+	cells := []Cell{NewCell(10, 10), NewCell(13, 10), NewCell(10, 14), NewCell(60, 60)}
+	g, e := gaussianKernel, epanechnikovKernel
+	for _, tc := range []struct {
+		kernel    string
+		bandwidth float64
+		want      []float64
+	}{
+		{"gaussian", 2, []float64{g(1.5) + g(2), g(1.5) + g(2.5), g(2) + g(2.5), 0}},
+		{"", 2, []float64{g(1.5) + g(2), g(1.5) + g(2.5), g(2) + g(2.5), 0}},
+		{"epanechnikov", 4.5, []float64{e(3/4.5) + e(4/4.5), e(3 / 4.5), e(4 / 4.5), 0}},
+	} {
+		for _, index := range IndexKinds {
+			p := DefaultConfig().Params()
+			p.Kernel, p.Bandwidth, p.Index = tc.kernel, tc.bandwidth, index
+			got := kernelDensities(cells, p)
+			for i := range got {
+				if !near(got[i], tc.want[i]) {
+					t.Errorf("%q kernel, bandwidth %g, %s index: densities %v, want %v", tc.kernel, tc.bandwidth, index, got, tc.want)
+					break
+				}
+			}
+		}
+	}
+}
+
+/*
+	TestKernelCutoff checks that the gaussian kernel stops at 3 bandwidths, where it is
+	not yet 0, and the epanechnikov kernel at 1.
+*/
+
+func TestKernelCutoff(t *testing.T) {
+	p := DefaultConfig().Params()
+	p.Bandwidth = 2
+	inside := []Cell{NewCell(10, 10), NewCell(15.9, 10)}
+	outside := []Cell{NewCell(10, 10), NewCell(16.1, 10)}
+	if got, want := kernelDensities(inside, p)[0], gaussianKernel(5.9/2); !near(got, want) {
+		t.Errorf("gaussian density of a cell 2.95 bandwidths away %g, want %g", got, want)
+	}
+	if got := kernelDensities(outside, p)[0]; got != 0 {
+		t.Errorf("gaussian density of a cell 3.05 bandwidths away %g, want 0 past the cutoff", got)
+	}
+	p.Kernel = "epanechnikov"
+	if got := kernelDensities([]Cell{NewCell(10, 10), NewCell(12, 10)}, p)[0]; got != 0 {
+		t.Errorf("epanechnikov density of a cell 1 bandwidth away %g, want 0", got)
+	}
+}
+
+// TestKernelBandwidth checks that a bandwidth of 0 falls back to searchRadius
+func TestKernelBandwidth(t *testing.T) {
+	cells := []Cell{NewCell(10, 10), NewCell(13, 10), NewCell(10, 14), NewCell(25, 10)}
+	for _, kernel := range KernelKinds {
+		p := DefaultConfig().Params()
+		p.Kernel, p.SearchRadius = kernel, 6
+		fallback := kernelDensities(cells, p)
+		p.Bandwidth = 6
+		if set := kernelDensities(cells, p); !near(fallback[0], set[0]) || !near(fallback[3], set[3]) {
+			t.Errorf("%s kernel: densities %v with bandwidth 0, %v with the bandwidth searchRadius", kernel, fallback, set)
+		}
+		p.Bandwidth = 3
+		if other := kernelDensities(cells, p); near(fallback[0], other[0]) {
+			t.Errorf("%s kernel: bandwidth 0 gives the densities of bandwidth 3, not searchRadius", kernel)
+		}
+	}
+}
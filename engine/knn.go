@@ -0,0 +1,73 @@
+package engine
+
+import (
+	"math"
+	"sort"
+)
+
+func init() {
+	RegisterDensity("KNN", knnEstimator{})
+}
+
+// knnMinRadius is the smallest distance to the k-th neighbour, so that cells on top of each other have a finite density
+const knnMinRadius = 1e-6
+
+/*
+	knnEstimator is the KNN strategy: the density of a cell is k divided by the area of
+	the smallest disc around it that reaches its k-th nearest neighbour, with k from
+	p.K. Unlike CountDensity it tells apart the cells of a sparse colony, where few
+	have a neighbour within searchRadius. With k or fewer other cells on the board, the
+	farthest of them is used with their number instead of k, and a lone cell has a
+	density of 0.
+
+	The neighbours are searched for with the spatial index named by p.Index, starting
+	within searchRadius and doubling the radius until k of them are found.
+*/
+
+type knnEstimator struct{}
+
+func (knnEstimator) Density(cells []Cell, width float64, p Params) []Cell {
+	k := p.K
+	if k < 1 {
+		k = 1
+	}
+	if k > len(cells)-1 {
+		k = len(cells) - 1
+	}
+	if k < 1 {
+		for i := range cells {
+			cells[i].density = 0
+		}
+		return cells
+	}
+
+	start := p.SearchRadius
+	if start <= 0 {
+		start = 1
+	}
+	index := NewSpatialIndex(p.Index, cells, width, start)
+	forChunks(len(cells), p.Workers, func(_, lo, hi int) {
+		var near []int
+		var dists []float64
+		for i := lo; i < hi; i++ {
+			r := start
+			for {
+				near = index.Neighbours(cells[i].x, cells[i].y, r, near[:0])
+				if len(near)-1 >= k {
+					break
+				}
+				r *= 2
+			}
+			dists = dists[:0]
+			for _, j := range near {
+				if j != i {
+					dists = append(dists, math.Hypot(cells[i].x-cells[j].x, cells[i].y-cells[j].y))
+				}
+			}
+			sort.Float64s(dists)
+			rk := math.Max(dists[k-1], knnMinRadius)
+			cells[i].density = float64(k) / (math.Pi * rk * rk)
+		}
+	})
+	return cells
+}
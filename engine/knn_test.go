@@ -0,0 +1,83 @@
+package engine
+
+import (
+	"math"
+	"testing"
+)
+
+// knnDensities returns the densities the KNN strategy gives cells on a board 100 wide, with k and index
+func knnDensities(cells []Cell, k int, index string) []float64 {
+	p := DefaultConfig().Params()
+	p.K, p.Index = k, index
+	cells = knnEstimator{}.Density(append([]Cell(nil), cells...), 100, p)
+	densities := make([]float64, len(cells))
+	for i, c := range cells {
+		densities[i] = c.density
+	}
+	return densities
+}
+
+// disc is the density of k cells in a disc of radius r
+func disc(k int, r float64) float64 {
+	return float64(k) / (math.Pi * r * r)
+}
+
+/*
+	TestKNNDensity places four cells on a line, 3, 4 and 30 apart, the last far beyond
+	searchRadius, and checks that each density is k over the area of the disc that
+	reaches the k-th nearest neighbour.
+*/
+
+func TestKNNDensity(t *testing.T) {
+	cells := []Cell{NewCell(10, 10), NewCell(13, 10), NewCell(17, 10), NewCell(47, 10)}
+	for _, tc := range []struct {
+		k    int
+		want []float64
+	}{
+		{1, []float64{disc(1, 3), disc(1, 3), disc(1, 4), disc(1, 30)}},
+		{2, []float64{disc(2, 7), disc(2, 4), disc(2, 7), disc(2, 34)}},
+		{3, []float64{disc(3, 37), disc(3, 34), disc(3, 30), disc(3, 37)}},
+		{0, []float64{disc(1, 3), disc(1, 3), disc(1, 4), disc(1, 30)}},
+	} {
+		for _, index := range IndexKinds {
+			got := knnDensities(cells, tc.k, index)
+			for i := range got {
+				if !near(got[i], tc.want[i]) {
+					t.Errorf("k %d, %s index: densities %v, want %v", tc.k, index, got, tc.want)
+					break
+				}
+			}
+		}
+	}
+}
+
+// TestKNNFewCells checks that k is clamped to the number of other cells, and that a lone cell has a density of 0
+func TestKNNFewCells(t *testing.T) {
+	cells := []Cell{NewCell(10, 10), NewCell(13, 10), NewCell(17, 10)}
+	got, want := knnDensities(cells, 5, "grid"), knnDensities(cells, 2, "grid")
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("k 5 with 3 cells: densities %v, want those of k 2, %v", got, want)
+			break
+		}
+	}
+	if got := knnDensities([]Cell{NewCell(10, 10)}, 5, "grid"); got[0] != 0 {
+		t.Errorf("a lone cell has density %g, want 0", got[0])
+	}
+	if got := knnDensities(nil, 5, "grid"); len(got) != 0 {
+		t.Errorf("densities %v of no cells", got)
+	}
+}
+
+// TestKNNCoincident checks that cells on top of each other have the finite density of knnMinRadius
+func TestKNNCoincident(t *testing.T) {
+	cells := []Cell{NewCell(10, 10), NewCell(10, 10), NewCell(20, 10)}
+	got := knnDensities(cells, 1, "grid")
+	want := disc(1, knnMinRadius)
+	if math.IsInf(got[0], 0) || math.IsNaN(got[0]) || !near(got[0], want) || !near(got[1], want) {
+		t.Errorf("coincident cells have densities %g and %g, want %g", got[0], got[1], want)
+	}
+	if !near(got[2], disc(1, 10)) {
+		t.Errorf("the third cell has density %g, want %g", got[2], disc(1, 10))
+	}
+}
@@ -0,0 +1,189 @@
+package engine
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// LineageFormats are the formats a Lineage can be written in
+var LineageFormats = []string{"newick", "json"}
+
+/*
+	Lineage records every cell seen on the boards of a run, with the cell it was born
+	from, so that the clones within a cluster can be traced back to the cells the run
+	started with. Boards are added one generation after another, as UpdateBoardStream
+	makes them. A cell that is born and dies in the same update is never on a board,
+	and so never recorded; it cannot have given birth.
+*/
+
+type Lineage struct {
+	nodes map[int]*LineageNode
+	last  int   // the last generation added
+	seed  int64 // seed of the run, written with the tree
+}
+
+// LineageNode is one cell of a Lineage
+type LineageNode struct {
+	ID       int `json:"id"`
+	Parent   int `json:"parent"`         // 0 for a founder
+	CellType int `json:"celltype"`       // 0 in one cluster runs, 1 for a source and 2 for a sink
+	Born     int `json:"born"`           // generation it was born in
+	Died     int `json:"died,omitempty"` // first generation it was no longer on the board, 0 if it is alive at the end
+	Depth    int `json:"depth"`          // births between it and its founder
+
+	seen     int // last generation it was on the board
+	children []int
+}
+
+// NewLineage returns an empty Lineage of the run of seed
+func NewLineage(seed int64) *Lineage {
+	return &Lineage{nodes: make(map[int]*LineageNode), seed: seed}
+}
+
+// AddBoard records the cells of board
+func (l *Lineage) AddBoard(board GameBoard) {
+	l.add(board.generation, board.cells)
+}
+
+// AddTwoClusterBoard records the sources and sinks of board
+func (l *Lineage) AddTwoClusterBoard(board TwoClusterBoard) {
+	for _, cells := range board.cells {
+		l.add(board.generation, cells)
+	}
+}
+
+func (l *Lineage) add(generation int, cells []Cell) {
+	for _, c := range cells {
+		node, ok := l.nodes[c.id]
+		if !ok {
+			node = &LineageNode{ID: c.id, Parent: c.parent, CellType: c.celltype, Born: c.born, Depth: c.depth}
+			l.nodes[c.id] = node
+			if parent, ok := l.nodes[c.parent]; ok {
+				parent.children = append(parent.children, c.id)
+			}
+		}
+		node.seen = generation
+	}
+	l.last = generation
+}
+
+// Nodes returns every cell recorded, in order of id, with Died filled in
+func (l *Lineage) Nodes() []LineageNode {
+	nodes := make([]LineageNode, 0, len(l.nodes))
+	for _, node := range l.nodes {
+		n := *node
+		if n.seen < l.last {
+			n.Died = n.seen + 1
+		}
+		n.children = nil
+		nodes = append(nodes, n)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+	return nodes
+}
+
+/*
+	Restore records nodes, as Nodes returned them at generation, in l, so that the
+	lineage of a run resumed from a checkpoint at generation keeps the cells that died
+	before it. Boards from generation on can then be added as if l had seen every
+	board of the run.
+*/
+
+func (l *Lineage) Restore(nodes []LineageNode, generation int) {
+	for _, n := range nodes {
+		node := n
+		node.seen = generation
+		if node.Died > 0 {
+			node.seen = node.Died - 1
+		}
+		node.Died = 0
+		l.nodes[node.ID] = &node
+	}
+	for _, n := range nodes {
+		if parent, ok := l.nodes[n.Parent]; ok {
+			parent.children = append(parent.children, n.ID)
+		}
+	}
+	l.last = generation
+}
+
+// roots returns the ids of the cells whose parent was never recorded, in order
+func (l *Lineage) roots() []int {
+	roots := []int{}
+	for id, node := range l.nodes {
+		if _, ok := l.nodes[node.Parent]; !ok {
+			roots = append(roots, id)
+		}
+	}
+	sort.Ints(roots)
+	return roots
+}
+
+// Write writes l to w in format, one of LineageFormats
+func (l *Lineage) Write(w io.Writer, format string) error {
+	switch format {
+	case "newick":
+		return l.WriteNewick(w)
+	case "json":
+		return l.WriteJSON(w)
+	}
+	return fmt.Errorf("unknown lineage format %q", format)
+}
+
+/*
+	WriteNewick writes one Newick tree per founder, each on a line of its own and
+	starting with the comment "[seed: N]". Every cell is a node labelled by its id, with
+	its daughters as children in the order they were born, and the length of its branch
+	is the number of generations between its birth and its parent's.
+*/
+
+func (l *Lineage) WriteNewick(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, root := range l.roots() {
+		bw.WriteString("[" + SeedComment(l.seed) + "]")
+		l.newick(bw, root)
+		bw.WriteString(";\n")
+	}
+	return bw.Flush()
+}
+
+func (l *Lineage) newick(w *bufio.Writer, id int) {
+	node := l.nodes[id]
+	if len(node.children) > 0 {
+		sort.Ints(node.children) // ids are given in order of birth
+		w.WriteByte('(')
+		for i, child := range node.children {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			l.newick(w, child)
+		}
+		w.WriteByte(')')
+	}
+	w.WriteString(strconv.Itoa(id))
+	if parent, ok := l.nodes[node.Parent]; ok {
+		w.WriteByte(':')
+		w.WriteString(strconv.Itoa(node.Born - parent.Born))
+	}
+}
+
+/*
+	WriteJSON writes the seed of the run, the last generation added and every cell
+	recorded, as given by Nodes. The tree is kept by the parent of each cell.
+*/
+
+func (l *Lineage) WriteJSON(w io.Writer) error {
+	out := struct {
+		Seed       int64         `json:"seed"`
+		Generation int           `json:"generation"`
+		Roots      []int         `json:"roots"`
+		Cells      []LineageNode `json:"cells"`
+	}{l.seed, l.last, l.roots(), l.Nodes()}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
@@ -0,0 +1,147 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// lineageCell is a cell with the given id, parent and generation of birth
+func lineageCell(id, parent, born int) Cell {
+	c := NewCell(float64(id), float64(id))
+	c.id, c.parent, c.born = id, parent, born
+	if parent != 0 {
+		c.depth = 1
+	}
+	return c
+}
+
+/*
+	knownLineage has founders 1 and 2. 1 gives birth to 3 in generation 1 and to 5 in
+	generation 2, when 3 gives birth to 4 and 2 dies.
+*/
+
+func knownLineage() *Lineage {
+	l := NewLineage(9)
+	l.AddBoard(GameBoard{generation: 0, cells: []Cell{lineageCell(1, 0, 0), lineageCell(2, 0, 0)}})
+	l.AddBoard(GameBoard{generation: 1, cells: []Cell{lineageCell(1, 0, 0), lineageCell(2, 0, 0), lineageCell(3, 1, 1)}})
+	l.AddBoard(GameBoard{generation: 2, cells: []Cell{lineageCell(1, 0, 0), lineageCell(3, 1, 1), lineageCell(4, 3, 2), lineageCell(5, 1, 2)}})
+	return l
+}
+
+func TestLineageNewick(t *testing.T) {
+	var buf bytes.Buffer
+	if err := knownLineage().Write(&buf, "newick"); err != nil {
+		t.Fatal(err)
+	}
+	want := "[seed: 9]((4:1)3:1,5:2)1;\n[seed: 9]2;\n"
+	if buf.String() != want {
+		t.Errorf("newick\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestLineageJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := knownLineage().Write(&buf, "json"); err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Seed       int64
+		Generation int
+		Roots      []int
+		Cells      []LineageNode
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Seed != 9 || got.Generation != 2 || !reflect.DeepEqual(got.Roots, []int{1, 2}) {
+		t.Errorf("seed %d, generation %d, roots %v; want 9, 2, [1 2]", got.Seed, got.Generation, got.Roots)
+	}
+	want := []LineageNode{
+		{ID: 1},
+		{ID: 2, Died: 2},
+		{ID: 3, Parent: 1, Born: 1, Depth: 1},
+		{ID: 4, Parent: 3, Born: 2, Depth: 1},
+		{ID: 5, Parent: 1, Born: 2, Depth: 1},
+	}
+	if !reflect.DeepEqual(got.Cells, want) {
+		t.Errorf("cells\n%+v\nwant\n%+v", got.Cells, want)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("{\n  \"seed\": 9,\n  \"generation\": 2,")) {
+		t.Errorf("json does not start with the seed and generation:\n%s", buf.String())
+	}
+}
+
+func TestLineageUnknownFormat(t *testing.T) {
+	if err := knownLineage().Write(&bytes.Buffer{}, "nexus"); err == nil {
+		t.Error("no error writing an unknown format")
+	}
+}
+
+/*
+	TestLineageResume records the lineage of a run, and of the same run checkpointed
+	part way with its lineage and resumed, which must write the same tree: the cells
+	that died before the checkpoint included.
+*/
+
+func TestLineageResume(t *testing.T) {
+	cfg := testConfig()
+	cfg.DeathMode = "both"
+	p := cfg.Params()
+	start := func() (GameBoard, *Source) {
+		src := NewSource(cfg.Seed)
+		return InitializeBoard(cfg.InitialCells, cfg.BirthRadius, cfg.Width, rand.New(src)), src
+	}
+	write := func(l *Lineage, format string) string {
+		var buf bytes.Buffer
+		if err := l.Write(&buf, format); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+
+	board, _ := start()
+	whole := NewLineage(cfg.Seed)
+	UpdateBoardStream(board, resumeGens, p, func(gen int, b GameBoard) error {
+		whole.AddBoard(b)
+		return nil
+	})
+
+	board, src := start()
+	part := NewLineage(cfg.Seed)
+	var checkpoint Checkpoint
+	UpdateBoardStream(board, resumeAt, p, func(gen int, b GameBoard) error {
+		part.AddBoard(b)
+		checkpoint = NewCheckpoint(b, cfg, src)
+		return nil
+	})
+	checkpoint.Lineage = part.Nodes()
+	checkpoint = saveAndLoad(t, checkpoint)
+	board, _, err := checkpoint.GameBoard()
+	if err != nil {
+		t.Fatal(err)
+	}
+	resumed := NewLineage(cfg.Seed)
+	resumed.Restore(checkpoint.Lineage, checkpoint.Generation)
+	UpdateBoardStream(board, resumeGens-resumeAt, p, func(gen int, b GameBoard) error {
+		resumed.AddBoard(b)
+		return nil
+	})
+
+	died := 0
+	for _, n := range checkpoint.Lineage {
+		if n.Died != 0 {
+			died++
+		}
+	}
+	if died == 0 {
+		t.Fatal("no cell died before the checkpoint, so the test shows nothing")
+	}
+	for _, format := range LineageFormats {
+		if got, want := write(resumed, format), write(whole, format); got != want {
+			t.Errorf("%s lineage of the resumed run differs from the uninterrupted run's", format)
+		}
+	}
+}
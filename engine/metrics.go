@@ -0,0 +1,209 @@
+package engine
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+)
+
+/*
+	Metrics summarises one generation of a board. Density is the number of other cells
+	within searchRadius of a cell, measured on the board as it is, so it can be compared
+	between strategies. Radius is the root mean square distance of the cells from their
+	centroid. ZoneOccupancy counts the cells inside each zone of a one cluster board.
+	Sources, Sinks, TotalSignal and SinkSignal (the number of sinks at each signal level
+	from 0 to 3) are only filled for the source and sink model, whose density is
+	summarised for each type of cell in SourceDensity and SinkDensity instead of
+	Density, counting only the other cells of the same type as the model does.
+*/
+
+type Metrics struct {
+	Generation    int
+	Population    int
+	Births        int
+	Deaths        int
+	Density       DensitySummary
+	Radius        float64
+	CentroidX     float64
+	CentroidY     float64
+	ZoneOccupancy []int
+
+	Sources       int
+	Sinks         int
+	SourceDensity DensitySummary
+	SinkDensity   DensitySummary
+	TotalSignal   int
+	SinkSignal    [signalLevels]int
+}
+
+// DensitySummary is the mean, median and largest density of a group of cells
+type DensitySummary struct {
+	Mean   float64
+	Median float64
+	Max    float64
+}
+
+// signalLevels is the number of signal levels a sink can have, from 0 to 3
+const signalLevels = 4
+
+// BoardMetrics returns the Metrics of a one cluster board
+func BoardMetrics(board GameBoard, searchRadius float64) Metrics {
+	var m Metrics
+	m.Generation = board.generation
+	m.Births, m.Deaths = board.births, board.deaths
+	m.place(board.cells)
+	m.Density = summariseDensity(board.cells, board.width, searchRadius)
+	m.ZoneOccupancy = make([]int, len(board.zone))
+	for z, zone := range board.zone {
+		for i := range board.cells {
+			xdiff := board.cells[i].x - zone.centrex
+			ydiff := board.cells[i].y - zone.centrey
+			if math.Sqrt(xdiff*xdiff+ydiff*ydiff) < zone.radius {
+				m.ZoneOccupancy[z]++
+			}
+		}
+	}
+	return m
+}
+
+// TwoClusterMetrics returns the Metrics of a source and sink board, with the density of sources and sinks apart
+func TwoClusterMetrics(board TwoClusterBoard, searchRadius float64) Metrics {
+	var m Metrics
+	m.Generation = board.generation
+	m.Births, m.Deaths = board.births, board.deaths
+	m.place(append(append([]Cell(nil), board.cells[0]...), board.cells[1]...))
+	m.Sources = len(board.cells[0])
+	m.Sinks = len(board.cells[1])
+	m.SourceDensity = summariseDensity(board.cells[0], board.width, searchRadius)
+	m.SinkDensity = summariseDensity(board.cells[1], board.width, searchRadius)
+	m.TotalSignal = board.totalsignal
+	for _, sink := range board.cells[1] {
+		if sink.signalLevel >= 0 && sink.signalLevel < signalLevels {
+			m.SinkSignal[sink.signalLevel]++
+		}
+	}
+	return m
+}
+
+// place fills in the population, centroid and radius of cells
+func (m *Metrics) place(cells []Cell) {
+	m.Population = len(cells)
+	if len(cells) == 0 {
+		return
+	}
+	for i := range cells {
+		m.CentroidX += cells[i].x
+		m.CentroidY += cells[i].y
+	}
+	n := float64(len(cells))
+	m.CentroidX /= n
+	m.CentroidY /= n
+
+	for i := range cells {
+		dx := cells[i].x - m.CentroidX
+		dy := cells[i].y - m.CentroidY
+		m.Radius += dx*dx + dy*dy
+	}
+	m.Radius = math.Sqrt(m.Radius / n)
+}
+
+// summariseDensity counts the other cells of cells within searchRadius of each, and summarises the counts
+func summariseDensity(cells []Cell, width, searchRadius float64) DensitySummary {
+	var d DensitySummary
+	if len(cells) == 0 {
+		return d
+	}
+
+	grid := NewGrid(cells, width, searchRadius)
+	densities := make([]float64, len(cells))
+	for i := range cells {
+		densities[i] = float64(grid.Count(cells[i].x, cells[i].y, searchRadius) - 1)
+		d.Mean += densities[i]
+	}
+	d.Mean /= float64(len(cells))
+
+	sort.Float64s(densities)
+	d.Max = densities[len(densities)-1]
+	if len(densities)%2 == 1 {
+		d.Median = densities[len(densities)/2]
+	} else {
+		d.Median = (densities[len(densities)/2-1] + densities[len(densities)/2]) / 2
+	}
+	return d
+}
+
+/*
+	MetricsWriter writes Metrics as CSV, one row per generation, under a comment row
+	"# seed: N" and a header that names every column. The columns depend on the model:
+	one cluster boards have the mean, median and max density of their cells and a zoneN
+	column for each of their zones; source and sink boards have those of the sources and
+	of the sinks apart, the source and sink counts, the total signal and a sinkSignalN
+	column for each signal level.
+*/
+
+type MetricsWriter struct {
+	w     *csv.Writer
+	model string
+	zones int
+}
+
+// NewMetricsWriter returns a MetricsWriter for a run of seed of the given model, one of ModelOneCluster or ModelTwoCluster
+func NewMetricsWriter(w io.Writer, model string, zones int, seed int64) *MetricsWriter {
+	mw := &MetricsWriter{w: csv.NewWriter(w), model: model, zones: zones}
+	mw.w.Write([]string{"# " + SeedComment(seed)})
+	header := []string{"generation", "population", "births", "deaths"}
+	if model == ModelTwoCluster {
+		header = append(header, "sourceMeanDensity", "sourceMedianDensity", "sourceMaxDensity", "sinkMeanDensity", "sinkMedianDensity", "sinkMaxDensity")
+	} else {
+		header = append(header, "meanDensity", "medianDensity", "maxDensity")
+	}
+	header = append(header, "radius", "centroidX", "centroidY")
+	if model == ModelTwoCluster {
+		header = append(header, "sources", "sinks", "totalsignal")
+		for level := 0; level < signalLevels; level++ {
+			header = append(header, "sinkSignal"+strconv.Itoa(level))
+		}
+	} else {
+		for z := 0; z < zones; z++ {
+			header = append(header, "zone"+strconv.Itoa(z))
+		}
+	}
+	mw.w.Write(header)
+	return mw
+}
+
+// Write writes the row of m
+func (mw *MetricsWriter) Write(m Metrics) error {
+	f := func(v float64) string { return strconv.FormatFloat(v, 'g', -1, 64) }
+	density := func(d DensitySummary) []string { return []string{f(d.Mean), f(d.Median), f(d.Max)} }
+	row := []string{strconv.Itoa(m.Generation), strconv.Itoa(m.Population), strconv.Itoa(m.Births), strconv.Itoa(m.Deaths)}
+	if mw.model == ModelTwoCluster {
+		row = append(append(row, density(m.SourceDensity)...), density(m.SinkDensity)...)
+	} else {
+		row = append(row, density(m.Density)...)
+	}
+	row = append(row, f(m.Radius), f(m.CentroidX), f(m.CentroidY))
+	if mw.model == ModelTwoCluster {
+		row = append(row, strconv.Itoa(m.Sources), strconv.Itoa(m.Sinks), strconv.Itoa(m.TotalSignal))
+		for _, count := range m.SinkSignal {
+			row = append(row, strconv.Itoa(count))
+		}
+	} else {
+		if len(m.ZoneOccupancy) != mw.zones {
+			return fmt.Errorf("metrics of generation %d have %d zones, expected %d", m.Generation, len(m.ZoneOccupancy), mw.zones)
+		}
+		for _, count := range m.ZoneOccupancy {
+			row = append(row, strconv.Itoa(count))
+		}
+	}
+	return mw.w.Write(row)
+}
+
+// Flush writes any buffered rows, and returns the first error met while writing
+func (mw *MetricsWriter) Flush() error {
+	mw.w.Flush()
+	return mw.w.Error()
+}
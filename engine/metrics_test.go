@@ -0,0 +1,144 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/csv"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// near reports whether a and b are equal but for rounding
+func near(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-9*math.Max(1, math.Abs(b))
+}
+
+func TestBoardMetrics(t *testing.T) {
+	board := GameBoard{
+		cells:      []Cell{NewCell(10, 10), NewCell(12, 10), NewCell(50, 40)},
+		width:      100,
+		zone:       []Zone{{centrex: 11, centrey: 10, radius: 3}, {centrex: 80, centrey: 80, radius: 5}},
+		generation: 4,
+		births:     2,
+		deaths:     1,
+	}
+	m := BoardMetrics(board, 5)
+	if m.Generation != 4 || m.Population != 3 || m.Births != 2 || m.Deaths != 1 {
+		t.Errorf("generation %d, population %d, births %d, deaths %d; want 4, 3, 2, 1", m.Generation, m.Population, m.Births, m.Deaths)
+	}
+	if want := (DensitySummary{Mean: 2.0 / 3, Median: 1, Max: 1}); !near(m.Density.Mean, want.Mean) || m.Density.Median != want.Median || m.Density.Max != want.Max {
+		t.Errorf("density %+v, want %+v", m.Density, want)
+	}
+	if !near(m.CentroidX, 24) || !near(m.CentroidY, 20) {
+		t.Errorf("centroid (%g, %g), want (24, 20)", m.CentroidX, m.CentroidY)
+	}
+	if want := math.Sqrt((14*14 + 10*10 + 12*12 + 10*10 + 26*26 + 20*20) / 3.0); !near(m.Radius, want) {
+		t.Errorf("radius %g, want %g", m.Radius, want)
+	}
+	if !reflect.DeepEqual(m.ZoneOccupancy, []int{2, 0}) {
+		t.Errorf("zone occupancy %v, want [2 0]", m.ZoneOccupancy)
+	}
+}
+
+// twoClusterMetricsBoard has two sources next to each other and sinks, one of them between the sources
+func twoClusterMetricsBoard() TwoClusterBoard {
+	source := func(x, y float64) Cell {
+		c := NewCell(x, y)
+		c.celltype = 1
+		return c
+	}
+	sink := func(x, y float64, signal int) Cell {
+		c := NewCell(x, y)
+		c.celltype, c.signalLevel = 2, signal
+		return c
+	}
+	return TwoClusterBoard{
+		cells: [][]Cell{
+			{source(10, 10), source(12, 10)},
+			{sink(11, 10, 0), sink(60, 60, 3), sink(61, 60, 3), sink(90, 90, 1)},
+		},
+		width:       100,
+		totalsignal: 7,
+		generation:  2,
+	}
+}
+
+/*
+	TestTwoClusterMetrics checks that sources and sinks are summarised apart: the sink
+	between the two sources must not count as their neighbour, nor they as its.
+*/
+
+func TestTwoClusterMetrics(t *testing.T) {
+	m := TwoClusterMetrics(twoClusterMetricsBoard(), 5)
+	if m.Population != 6 || m.Sources != 2 || m.Sinks != 4 || m.TotalSignal != 7 {
+		t.Errorf("population %d, sources %d, sinks %d, signal %d; want 6, 2, 4, 7", m.Population, m.Sources, m.Sinks, m.TotalSignal)
+	}
+	if want := (DensitySummary{Mean: 1, Median: 1, Max: 1}); m.SourceDensity != want {
+		t.Errorf("source density %+v, want %+v", m.SourceDensity, want)
+	}
+	if want := (DensitySummary{Mean: 0.5, Median: 0.5, Max: 1}); m.SinkDensity != want {
+		t.Errorf("sink density %+v, want %+v", m.SinkDensity, want)
+	}
+	if m.Density != (DensitySummary{}) {
+		t.Errorf("pooled density %+v is filled for a source and sink board", m.Density)
+	}
+	if m.SinkSignal != [signalLevels]int{1, 1, 0, 2} {
+		t.Errorf("sinks at each signal level %v, want [1 1 0 2]", m.SinkSignal)
+	}
+}
+
+// readMetrics writes rows with a MetricsWriter for model and reads the CSV back, without its comment row
+func readMetrics(t *testing.T, model string, zones int, rows ...Metrics) [][]string {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := NewMetricsWriter(&buf, model, zones, 11)
+	for _, m := range rows {
+		if err := mw.Write(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := mw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if line, _ := buf.ReadString('\n'); line != "# seed: 11\n" {
+		t.Errorf("first line %q, want the seed", line)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return records
+}
+
+func TestMetricsWriter(t *testing.T) {
+	board := GameBoard{cells: []Cell{NewCell(10, 10), NewCell(12, 10)}, width: 100, zone: []Zone{{centrex: 10, centrey: 10, radius: 1}}, generation: 3}
+	records := readMetrics(t, ModelOneCluster, 1, BoardMetrics(board, 5))
+	want := [][]string{
+		{"generation", "population", "births", "deaths", "meanDensity", "medianDensity", "maxDensity", "radius", "centroidX", "centroidY", "zone0"},
+		{"3", "2", "0", "0", "1", "1", "1", "1", "11", "10", "1"},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("one cluster metrics\n%v\nwant\n%v", records, want)
+	}
+
+	records = readMetrics(t, ModelTwoCluster, 0, TwoClusterMetrics(twoClusterMetricsBoard(), 5))
+	want = [][]string{
+		{"generation", "population", "births", "deaths",
+			"sourceMeanDensity", "sourceMedianDensity", "sourceMaxDensity", "sinkMeanDensity", "sinkMedianDensity", "sinkMaxDensity",
+			"radius", "centroidX", "centroidY", "sources", "sinks", "totalsignal", "sinkSignal0", "sinkSignal1", "sinkSignal2", "sinkSignal3"},
+		{"2", "6", "0", "0", "1", "1", "1", "0.5", "0.5", "1"},
+	}
+	if len(records) != 2 || !reflect.DeepEqual(records[0], want[0]) || !reflect.DeepEqual(records[1][:10], want[1]) {
+		t.Fatalf("two cluster metrics\n%v\nwant to start with\n%v", records, want)
+	}
+	if got := records[1][13:]; !reflect.DeepEqual(got, []string{"2", "4", "7", "1", "1", "0", "2"}) {
+		t.Errorf("two cluster counts %v, want [2 4 7 1 1 0 2]", got)
+	}
+}
+
+func TestMetricsWriterZones(t *testing.T) {
+	mw := NewMetricsWriter(&bytes.Buffer{}, ModelOneCluster, 2, 1)
+	if err := mw.Write(Metrics{Generation: 5, ZoneOccupancy: []int{1}}); err == nil {
+		t.Error("metrics of 1 zone written under a header of 2")
+	}
+}
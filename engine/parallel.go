@@ -0,0 +1,76 @@
+package engine
+
+import (
+	"math/rand"
+	"sync"
+)
+
+/*
+	chunkSize is the number of cells in each piece of work handed to a worker. It is
+	fixed, rather than derived from the number of workers, because births in a chunk
+	draw from that chunk's own random stream: keeping the chunks the same keeps the
+	boards the same however many workers share them.
+*/
+
+const chunkSize = 64
+
+/*
+	forChunks splits the items [0, n) into chunks of chunkSize and calls fn with the
+	number and bounds of every chunk, using up to workers goroutines. With one worker
+	(or fewer) the chunks run in order on the calling goroutine. fn must only write to
+	the items of its own chunk.
+*/
+
+func forChunks(n, workers int, fn func(chunk, lo, hi int)) {
+	chunks := (n + chunkSize - 1) / chunkSize
+	bounds := func(c int) (int, int) {
+		hi := (c + 1) * chunkSize
+		if hi > n {
+			hi = n
+		}
+		return c * chunkSize, hi
+	}
+
+	if workers <= 1 || chunks <= 1 {
+		for c := 0; c < chunks; c++ {
+			lo, hi := bounds(c)
+			fn(c, lo, hi)
+		}
+		return
+	}
+
+	if workers > chunks {
+		workers = chunks
+	}
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range work {
+				lo, hi := bounds(c)
+				fn(c, lo, hi)
+			}
+		}()
+	}
+	for c := 0; c < chunks; c++ {
+		work <- c
+	}
+	close(work)
+	wg.Wait()
+}
+
+/*
+	chunkRand returns the random stream of a chunk. seed is drawn once per generation
+	from the board's rng, which is seeded from the run seed, and is mixed with the
+	chunk number (splitmix64) so that neighbouring chunks get unrelated streams.
+*/
+
+func chunkRand(seed int64, chunk int) *rand.Rand {
+	z := uint64(seed) + uint64(chunk+1)*0x9E3779B97F4A7C15
+	z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9
+	z = (z ^ (z >> 27)) * 0x94D049BB133111EB
+	z ^= z >> 31
+	return rand.New(rand.NewSource(int64(z)))
+}
@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// testConfig is the default config with a board large enough to split the births into several chunks
+func testConfig() SimulationConfig {
+	cfg := DefaultConfig()
+	cfg.InitialCells = 300
+	cfg.BirthRadius = 60
+	cfg.NumZones = 2
+	cfg.Seed = 7
+	return cfg
+}
+
+func TestWorkersAgree(t *testing.T) {
+	for _, mode := range BirthModes {
+		for _, strategy := range []string{"CountDensity", "Voronoi", "Kernel", "KNN"} {
+			t.Run(mode+" "+strategy, func(t *testing.T) {
+				cfg := testConfig()
+				cfg.BirthMode = mode
+				cfg.Strategy = strategy
+				var want []CellState
+				for _, workers := range []int{1, 2, 7} {
+					cfg.Workers = workers
+					board := InitializeBoard(cfg.InitialCells, cfg.BirthRadius, cfg.Width, rand.New(NewSource(cfg.Seed))).AddZone(cfg.NumZones)
+					for gen := 0; gen < 8; gen++ {
+						board = board.UpdateOneBoard(cfg.Params())
+					}
+					got := cellStates(board.cells)
+					if want == nil {
+						want = got
+					} else if !reflect.DeepEqual(got, want) {
+						t.Errorf("%d workers give a different board of %d cells than 1 worker, with %d cells", workers, len(got), len(want))
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestWorkersAgreeTwoCluster(t *testing.T) {
+	for _, mode := range BirthModes {
+		cfg := testConfig()
+		cfg.BirthMode = mode
+		var want [2][]CellState
+		for _, workers := range []int{1, 2, 7} {
+			cfg.Workers = workers
+			board := InitializeTwoClusterBoard(cfg.InitialCells, cfg.BirthRadius, cfg.Width, rand.New(NewSource(cfg.Seed)))
+			for gen := 0; gen < 8; gen++ {
+				board = board.UpdateOneBoard(cfg.Params())
+			}
+			got := [2][]CellState{cellStates(board.Sources()), cellStates(board.Sinks())}
+			if want[0] == nil {
+				want = got
+			} else if !reflect.DeepEqual(got, want) {
+				t.Errorf("%s births: %d workers give a different board than 1 worker", mode, workers)
+			}
+		}
+	}
+}
+
+func TestBirthModesDiffer(t *testing.T) {
+	boards := make(map[string][]CellState)
+	for _, mode := range BirthModes {
+		cfg := testConfig()
+		cfg.BirthMode = mode
+		board := InitializeBoard(cfg.InitialCells, cfg.BirthRadius, cfg.Width, rand.New(NewSource(cfg.Seed)))
+		for gen := 0; gen < 5; gen++ {
+			board = board.UpdateOneBoard(cfg.Params())
+		}
+		boards[mode] = cellStates(board.cells)
+	}
+	if reflect.DeepEqual(boards["serial"], boards["chunked"]) {
+		t.Error("serial and chunked births give the same board, so the mode is not used")
+	}
+}
@@ -0,0 +1,164 @@
+package engine
+
+import "math"
+
+/*
+	Polygon is the Voronoi cell of a cell, clipped to the board: a closed polygon with
+	its vertices going counter clockwise, the last joined back to the first. Side k goes
+	from vertex k to vertex k+1, and is shared with the cell whose id is Side(k), or lies
+	on the border of the board where that is 0. Sides of no length, left where a
+	bisector passes through a vertex, are dropped.
+
+	The cells across the sides are named by their ids, which the cells of a board
+	always have. Cells made with NewCell have none until they are put on a board with
+	NewGameBoard: their id is 0, so a side shared with one of them reads as border, and
+	it is left out of Neighbours.
+*/
+
+type Polygon struct {
+	points []VPoint
+	sides  []int // id of the cell across each side, 0 for the border
+}
+
+/*
+	Polygon returns the Voronoi cell of c from the edges the Voronoi strategy gave it in
+	the last generation. It is empty for a cell of a board updated with any other
+	strategy; Polygons finds the cells of any board.
+*/
+
+func (c Cell) Polygon() Polygon {
+	var p Polygon
+	for _, e := range c.edges {
+		if *e.start != *e.end {
+			p.points = append(p.points, *e.start)
+			p.sides = append(p.sides, e.cell)
+		}
+	}
+	return p
+}
+
+/*
+	Polygons returns the Voronoi cell of each of cells on a board of the given width,
+	clipped to the board, indexed like cells, without changing them. Cells at the same
+	place have the same polygon.
+*/
+
+func Polygons(cells []Cell, width float64) []Polygon {
+	sites, polygons := VoronoiPolygons(cells, width)
+	out := make([]Polygon, len(cells))
+	for _, site := range sites {
+		polygon := newPolygon(cells, sites, polygons[site.index])
+		for _, i := range site.cells {
+			out[i] = polygon
+		}
+	}
+	return out
+}
+
+// newPolygon makes the Polygon of a clipped cell of the sites of cells, naming the first cell at each neighbouring site
+func newPolygon(cells []Cell, sites []*VSite, polygon cellPolygon) Polygon {
+	var p Polygon
+	n := len(polygon.points)
+	for k := 0; k < n; k++ {
+		if polygon.points[k] == polygon.points[(k+1)%n] {
+			continue
+		}
+		id := 0
+		if s := polygon.sides[k]; s >= 0 {
+			id = cells[sites[s].cells[0]].id
+		}
+		p.points = append(p.points, polygon.points[k])
+		p.sides = append(p.sides, id)
+	}
+	return p
+}
+
+// edges returns the sides of p as the Voronoi edges of a cell
+func (p Polygon) edges() []*VEdge {
+	n := len(p.points)
+	edges := make([]*VEdge, n)
+	for k := range edges {
+		edges[k] = &VEdge{start: &p.points[k], end: &p.points[(k+1)%n], cell: p.sides[k]}
+	}
+	return edges
+}
+
+// Len returns the number of vertices of p, which is also its number of sides
+func (p Polygon) Len() int { return len(p.points) }
+
+// Vertex returns vertex k of p
+func (p Polygon) Vertex(k int) (x, y float64) { return p.points[k].x, p.points[k].y }
+
+// Side returns the id of the cell across side k of p, or 0 if the side lies on the border of the board
+func (p Polygon) Side(k int) int { return p.sides[k] }
+
+// Area returns the area of p by the shoelace formula
+func (p Polygon) Area() float64 {
+	area := 0.0
+	n := len(p.points)
+	for k := 0; k < n; k++ {
+		a, b := p.points[k], p.points[(k+1)%n]
+		area += a.x*b.y - b.x*a.y
+	}
+	return area / 2
+}
+
+// Perimeter returns the length of the border of p, sides on the border of the board included
+func (p Polygon) Perimeter() float64 {
+	perimeter := 0.0
+	n := len(p.points)
+	for k := 0; k < n; k++ {
+		a, b := p.points[k], p.points[(k+1)%n]
+		perimeter += math.Hypot(b.x-a.x, b.y-a.y)
+	}
+	return perimeter
+}
+
+/*
+	Centroid returns the centre of mass of p, measured from its first vertex for
+	precision. A polygon with no area, which only a board of no width can give, has the
+	mean of its vertices instead.
+*/
+
+func (p Polygon) Centroid() (x, y float64) {
+	n := len(p.points)
+	if n == 0 {
+		return 0, 0
+	}
+	o := p.points[0]
+	var cx, cy, area float64
+	for k := 1; k+1 < n; k++ {
+		ax, ay := p.points[k].x-o.x, p.points[k].y-o.y
+		bx, by := p.points[k+1].x-o.x, p.points[k+1].y-o.y
+		cross := ax*by - bx*ay
+		area += cross
+		cx += (ax + bx) * cross
+		cy += (ay + by) * cross
+	}
+	if area == 0 {
+		for _, v := range p.points {
+			x += v.x
+			y += v.y
+		}
+		return x / float64(n), y / float64(n)
+	}
+	return o.x + cx/(3*area), o.y + cy/(3*area)
+}
+
+// Neighbours returns the ids of the cells sharing a side with p, each once, in order around it, leaving out those of id 0
+func (p Polygon) Neighbours() []int {
+	neighbours := []int{}
+	for _, id := range p.sides {
+		if id == 0 {
+			continue
+		}
+		seen := false
+		for _, other := range neighbours {
+			seen = seen || other == id
+		}
+		if !seen {
+			neighbours = append(neighbours, id)
+		}
+	}
+	return neighbours
+}
@@ -0,0 +1,115 @@
+package engine
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestPolygonMeasures(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		points    []VPoint
+		area      float64
+		perimeter float64
+		cx, cy    float64
+	}{
+		{"square", []VPoint{{10, 20}, {30, 20}, {30, 40}, {10, 40}}, 400, 80, 20, 30},
+		{"triangle", []VPoint{{0, 0}, {6, 0}, {0, 3}}, 9, 9 + math.Sqrt(45), 2, 1},
+		{"no area", []VPoint{{0, 0}, {4, 0}, {8, 0}}, 0, 16, 4, 0},
+		{"empty", nil, 0, 0, 0, 0},
+	} {
+		p := Polygon{points: tc.points, sides: make([]int, len(tc.points))}
+		if got := p.Area(); !near(got, tc.area) {
+			t.Errorf("%s: area %g, want %g", tc.name, got, tc.area)
+		}
+		if got := p.Perimeter(); !near(got, tc.perimeter) {
+			t.Errorf("%s: perimeter %g, want %g", tc.name, got, tc.perimeter)
+		}
+		if x, y := p.Centroid(); !near(x, tc.cx) || !near(y, tc.cy) {
+			t.Errorf("%s: centroid (%g, %g), want (%g, %g)", tc.name, x, y, tc.cx, tc.cy)
+		}
+	}
+}
+
+/*
+	TestPolygonsLattice splits a board 100 wide between four cells on a 2x2 lattice: each
+	has the quarter around it, and shares a side with the two cells next to it but not
+	with the one across the diagonal, which only touches it at the centre of the board.
+*/
+
+func TestPolygonsLattice(t *testing.T) {
+	board := NewGameBoard([]Cell{NewCell(25, 25), NewCell(75, 25), NewCell(25, 75), NewCell(75, 75)}, 100, rand.New(NewSource(1)))
+	neighbours := map[int][]int{1: {2, 3}, 2: {1, 4}, 3: {1, 4}, 4: {2, 3}}
+	for i, p := range Polygons(board.Cells(), board.Width()) {
+		c := board.Cells()[i]
+		if p.Len() != 4 || !near(p.Area(), 2500) || !near(p.Perimeter(), 200) {
+			t.Errorf("cell %d: %d vertices, area %g, perimeter %g; want 4, 2500, 200", c.ID(), p.Len(), p.Area(), p.Perimeter())
+		}
+		if x, y := p.Centroid(); !near(x, c.X()) || !near(y, c.Y()) {
+			t.Errorf("cell %d at (%g, %g) has its centroid at (%g, %g)", c.ID(), c.X(), c.Y(), x, y)
+		}
+		got := p.Neighbours()
+		sort.Ints(got)
+		if !reflect.DeepEqual(got, neighbours[c.ID()]) {
+			t.Errorf("cell %d has neighbours %v, want %v", c.ID(), got, neighbours[c.ID()])
+		}
+		border := 0
+		for k := 0; k < p.Len(); k++ {
+			if p.Side(k) == 0 {
+				border++
+			}
+		}
+		if border != 2 {
+			t.Errorf("cell %d has %d sides on the border, want 2", c.ID(), border)
+		}
+	}
+}
+
+/*
+	TestPolygonsCounterClockwise checks that the vertices of every polygon of a random
+	board go counter clockwise, every turn a left one, and that the polygons the Voronoi
+	strategy leaves on the cells are those Polygons finds.
+*/
+
+func TestPolygonsCounterClockwise(t *testing.T) {
+	rng := rand.New(NewSource(5))
+	cells := make([]Cell, 60)
+	for i := range cells {
+		cells[i] = NewCell(rng.Float64()*100, rng.Float64()*100)
+	}
+	board := NewGameBoard(cells, 100, rng)
+	polygons := Polygons(board.Cells(), board.Width())
+	board = Voronoi(&board)
+	for i, p := range polygons {
+		n := p.Len()
+		for k := 0; k < n; k++ {
+			ax, ay := p.Vertex(k)
+			bx, by := p.Vertex((k + 1) % n)
+			cx, cy := p.Vertex((k + 2) % n)
+			if cross := (bx-ax)*(cy-by) - (by-ay)*(cx-bx); cross < -1e-9 {
+				t.Fatalf("cell %d turns clockwise at vertex %d", board.Cells()[i].ID(), (k+1)%n)
+			}
+		}
+		if p.Area() <= 0 {
+			t.Errorf("cell %d has area %g", board.Cells()[i].ID(), p.Area())
+		}
+		if got := board.Cells()[i].Polygon(); !reflect.DeepEqual(got, p) {
+			t.Errorf("cell %d: Voronoi leaves %v on the cell, Polygons finds %v", board.Cells()[i].ID(), got, p)
+		}
+	}
+}
+
+// TestPolygonsUnnumbered checks that cells without ids have no neighbours, and have them once on a board
+func TestPolygonsUnnumbered(t *testing.T) {
+	cells := []Cell{NewCell(25, 50), NewCell(75, 50)}
+	if got := Polygons(cells, 100)[0].Neighbours(); len(got) != 0 {
+		t.Errorf("a cell of id 0 is a neighbour: %v", got)
+	}
+	board := NewGameBoard(cells, 100, rand.New(NewSource(1)))
+	if got := Polygons(board.Cells(), 100)[0].Neighbours(); !reflect.DeepEqual(got, []int{2}) {
+		t.Errorf("neighbours %v on a board, want [2]", got)
+	}
+}
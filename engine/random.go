@@ -0,0 +1,57 @@
+package engine
+
+import "math/rand"
+
+/*
+	Source is a rand.Source that counts the numbers drawn from it. Its state is then
+	just the seed and the count, which is what a checkpoint saves: RestoreSource seeds
+	a new Source and draws the same count again to get back to the same point.
+*/
+
+type Source struct {
+	seed  int64
+	draws uint64
+	src   rand.Source64
+}
+
+// RandState is the state of a Source: the seed it started from and how many numbers it has given
+type RandState struct {
+	Seed  int64  `json:"seed"`
+	Draws uint64 `json:"draws"`
+}
+
+// NewSource returns a Source that gives the same numbers as rand.NewSource(seed)
+func NewSource(seed int64) *Source {
+	return &Source{seed: seed, src: rand.NewSource(seed).(rand.Source64)}
+}
+
+// RestoreSource returns a Source in state, ready to give the numbers that come after it
+func RestoreSource(state RandState) *Source {
+	s := NewSource(state.Seed)
+	for i := uint64(0); i < state.Draws; i++ {
+		s.src.Uint64()
+	}
+	s.draws = state.Draws
+	return s
+}
+
+func (s *Source) Int63() int64 {
+	s.draws++
+	return s.src.Int63()
+}
+
+func (s *Source) Uint64() uint64 {
+	s.draws++
+	return s.src.Uint64()
+}
+
+func (s *Source) Seed(seed int64) {
+	s.seed = seed
+	s.draws = 0
+	s.src.Seed(seed)
+}
+
+// State returns the current state of s
+func (s *Source) State() RandState {
+	return RandState{Seed: s.seed, Draws: s.draws}
+}
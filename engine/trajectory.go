@@ -0,0 +1,235 @@
+package engine
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// TrajectoryFormats are the formats NewTrajectoryWriter can write
+var TrajectoryFormats = []string{"csv", "binary"}
+
+/*
+	TrajectoryWriter writes the state of every cell of a board, one generation after
+	another, so that the track of each cell can be followed by its id. Boards can be
+	written as UpdateBoardStream makes them, or from the slice UpdateBoard returns.
+	The seed of the run is written first, so a trajectory can be traced to its run.
+*/
+
+type TrajectoryWriter interface {
+	WriteBoard(board GameBoard) error
+	WriteTwoClusterBoard(board TwoClusterBoard) error
+	Flush() error
+}
+
+// NewTrajectoryWriter returns a TrajectoryWriter writing the run of seed to w in format, one of TrajectoryFormats
+func NewTrajectoryWriter(w io.Writer, format string, seed int64) (TrajectoryWriter, error) {
+	switch format {
+	case "csv":
+		return newCSVTrajectory(w, seed), nil
+	case "binary":
+		return newBinaryTrajectory(w, seed), nil
+	}
+	return nil, fmt.Errorf("unknown trajectory format %q", format)
+}
+
+/*
+	csvTrajectory writes one row per cell per generation, under the header
+	generation,id,x,y,density,celltype,signalLevel. The header follows a comment row
+	"# seed: N", which CSV readers skip with "#" as their comment character. The
+	sources of a source and sink board are written before its sinks.
+*/
+
+type csvTrajectory struct {
+	w *csv.Writer
+}
+
+func newCSVTrajectory(w io.Writer, seed int64) *csvTrajectory {
+	t := &csvTrajectory{csv.NewWriter(w)}
+	t.w.Write([]string{"# " + SeedComment(seed)})
+	t.w.Write([]string{"generation", "id", "x", "y", "density", "celltype", "signalLevel"})
+	return t
+}
+
+func (t *csvTrajectory) WriteBoard(board GameBoard) error {
+	return t.write(board.generation, board.cells)
+}
+
+func (t *csvTrajectory) WriteTwoClusterBoard(board TwoClusterBoard) error {
+	for _, cells := range board.cells {
+		if err := t.write(board.generation, cells); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *csvTrajectory) write(generation int, cells []Cell) error {
+	f := func(v float64) string { return strconv.FormatFloat(v, 'g', -1, 64) }
+	gen := strconv.Itoa(generation)
+	for _, c := range cells {
+		row := []string{gen, strconv.Itoa(c.id), f(c.x), f(c.y), f(c.density), strconv.Itoa(c.celltype), strconv.Itoa(c.signalLevel)}
+		if err := t.w.Write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *csvTrajectory) Flush() error {
+	t.w.Flush()
+	return t.w.Error()
+}
+
+/*
+	The binary trajectory format is little endian throughout. It starts with the 4 bytes
+	"CGTR", a uint16 version and the int64 seed of the run, then has one record per
+	generation: the generation and the number of cells as uint32, followed by that many
+	cells of 18 bytes each,
+
+		id        uint32
+		x, y      float32
+		density   float32
+		celltype  uint8
+		signal    uint8
+
+	A source and sink board writes its sources before its sinks in the same record, and
+	a trajectory of no generations is the header alone. ReadTrajectory reads the format
+	back.
+*/
+
+const (
+	trajectoryMagic   = "CGTR"
+	TrajectoryVersion = 2
+	trajectoryCell    = 18
+)
+
+type binaryTrajectory struct {
+	w       io.Writer
+	seed    int64
+	started bool
+	buf     []byte
+}
+
+func newBinaryTrajectory(w io.Writer, seed int64) *binaryTrajectory {
+	return &binaryTrajectory{w: w, seed: seed}
+}
+
+func (t *binaryTrajectory) WriteBoard(board GameBoard) error {
+	return t.write(board.generation, board.cells)
+}
+
+func (t *binaryTrajectory) WriteTwoClusterBoard(board TwoClusterBoard) error {
+	return t.write(board.generation, board.cells[0], board.cells[1])
+}
+
+func (t *binaryTrajectory) write(generation int, clusters ...[]Cell) error {
+	t.buf = t.header(t.buf[:0])
+	count := 0
+	for _, cells := range clusters {
+		count += len(cells)
+	}
+	t.buf = binary.LittleEndian.AppendUint32(t.buf, uint32(generation))
+	t.buf = binary.LittleEndian.AppendUint32(t.buf, uint32(count))
+	for _, cells := range clusters {
+		for _, c := range cells {
+			t.buf = binary.LittleEndian.AppendUint32(t.buf, uint32(c.id))
+			t.buf = binary.LittleEndian.AppendUint32(t.buf, math.Float32bits(float32(c.x)))
+			t.buf = binary.LittleEndian.AppendUint32(t.buf, math.Float32bits(float32(c.y)))
+			t.buf = binary.LittleEndian.AppendUint32(t.buf, math.Float32bits(float32(c.density)))
+			t.buf = append(t.buf, uint8(c.celltype), uint8(c.signalLevel))
+		}
+	}
+	_, err := t.w.Write(t.buf)
+	return err
+}
+
+// header appends the magic, version and seed to buf if they have not been written yet
+func (t *binaryTrajectory) header(buf []byte) []byte {
+	if t.started {
+		return buf
+	}
+	t.started = true
+	buf = append(buf, trajectoryMagic...)
+	buf = binary.LittleEndian.AppendUint16(buf, TrajectoryVersion)
+	return binary.LittleEndian.AppendUint64(buf, uint64(t.seed))
+}
+
+// Flush writes the header if no generation was written, so that an empty trajectory can still be read
+func (t *binaryTrajectory) Flush() error {
+	if t.started {
+		return nil
+	}
+	_, err := t.w.Write(t.header(nil))
+	return err
+}
+
+// Trajectory is a binary trajectory read back: the seed of its run and each of its generations
+type Trajectory struct {
+	Seed   int64
+	Frames []TrajectoryFrame
+}
+
+// TrajectoryFrame is one generation read back from a binary trajectory
+type TrajectoryFrame struct {
+	Generation int
+	Cells      []TrajectoryCell
+}
+
+// TrajectoryCell is the state of one cell in a TrajectoryFrame
+type TrajectoryCell struct {
+	ID          int
+	X, Y        float64
+	Density     float64
+	CellType    int
+	SignalLevel int
+}
+
+// ReadTrajectory reads the seed and every generation of a binary trajectory from r
+func ReadTrajectory(r io.Reader) (Trajectory, error) {
+	var trajectory Trajectory
+	br := bufio.NewReader(r)
+	header := make([]byte, len(trajectoryMagic)+2+8)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return trajectory, fmt.Errorf("trajectory header: %v", err)
+	}
+	if string(header[:len(trajectoryMagic)]) != trajectoryMagic {
+		return trajectory, errors.New("not a binary trajectory")
+	}
+	if v := binary.LittleEndian.Uint16(header[len(trajectoryMagic):]); v != TrajectoryVersion {
+		return trajectory, fmt.Errorf("trajectory version %d, expected %d", v, TrajectoryVersion)
+	}
+	trajectory.Seed = int64(binary.LittleEndian.Uint64(header[len(trajectoryMagic)+2:]))
+
+	record := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(br, record); err == io.EOF {
+			return trajectory, nil
+		} else if err != nil {
+			return trajectory, fmt.Errorf("trajectory record %d: %v", len(trajectory.Frames), err)
+		}
+		frame := TrajectoryFrame{Generation: int(binary.LittleEndian.Uint32(record))}
+		data := make([]byte, trajectoryCell*int(binary.LittleEndian.Uint32(record[4:])))
+		if _, err := io.ReadFull(br, data); err != nil {
+			return trajectory, fmt.Errorf("trajectory of generation %d: %v", frame.Generation, err)
+		}
+		frame.Cells = make([]TrajectoryCell, len(data)/trajectoryCell)
+		for i := range frame.Cells {
+			b := data[i*trajectoryCell:]
+			frame.Cells[i] = TrajectoryCell{
+				ID:          int(binary.LittleEndian.Uint32(b)),
+				X:           float64(math.Float32frombits(binary.LittleEndian.Uint32(b[4:]))),
+				Y:           float64(math.Float32frombits(binary.LittleEndian.Uint32(b[8:]))),
+				Density:     float64(math.Float32frombits(binary.LittleEndian.Uint32(b[12:]))),
+				CellType:    int(b[16]),
+				SignalLevel: int(b[17]),
+			}
+		}
+		trajectory.Frames = append(trajectory.Frames, frame)
+	}
+}
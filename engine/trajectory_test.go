@@ -0,0 +1,142 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/csv"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+func TestBinaryTrajectoryEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewTrajectoryWriter(&buf, "binary", -3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	trajectory, err := ReadTrajectory(&buf)
+	if err != nil {
+		t.Fatalf("reading a trajectory of no generations: %v", err)
+	}
+	if len(trajectory.Frames) != 0 {
+		t.Errorf("read %d generations from a trajectory of none", len(trajectory.Frames))
+	}
+	if trajectory.Seed != -3 {
+		t.Errorf("read seed %d, wrote -3", trajectory.Seed)
+	}
+}
+
+func TestBinaryTrajectoryRoundTrip(t *testing.T) {
+	cfg := testConfig()
+	board := InitializeBoard(cfg.InitialCells, cfg.BirthRadius, cfg.Width, rand.New(NewSource(cfg.Seed)))
+	var buf bytes.Buffer
+	w, err := NewTrajectoryWriter(&buf, "binary", cfg.Seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	boards := UpdateBoard(board, 3, cfg.Params())
+	for _, b := range boards {
+		if err := w.WriteBoard(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	trajectory, err := ReadTrajectory(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trajectory.Seed != cfg.Seed {
+		t.Errorf("read seed %d, wrote %d", trajectory.Seed, cfg.Seed)
+	}
+	frames := trajectory.Frames
+	if len(frames) != len(boards) {
+		t.Fatalf("read %d generations, wrote %d", len(frames), len(boards))
+	}
+	for i, frame := range frames {
+		cells := boards[i].Cells()
+		if frame.Generation != boards[i].Generation() || len(frame.Cells) != len(cells) {
+			t.Fatalf("generation %d with %d cells read back as generation %d with %d", boards[i].Generation(), len(cells), frame.Generation, len(frame.Cells))
+		}
+		for k, c := range frame.Cells {
+			if c.ID != cells[k].id || c.X != float64(float32(cells[k].x)) || c.Y != float64(float32(cells[k].y)) || c.Density != float64(float32(cells[k].density)) {
+				t.Fatalf("generation %d: cell %d read back as %+v", frame.Generation, cells[k].id, c)
+			}
+		}
+	}
+}
+
+/*
+	streamedTrajectory writes a trajectory in format as a run does, from UpdateBoardStream,
+	and returns the densities a fresh run of the estimator gives the cells of each
+	generation written, which the trajectory must hold.
+*/
+
+func streamedTrajectory(t *testing.T, format string, p Params) (*bytes.Buffer, [][]float64) {
+	t.Helper()
+	cfg := testConfig()
+	board := InitializeBoard(cfg.InitialCells, cfg.BirthRadius, cfg.Width, rand.New(NewSource(cfg.Seed)))
+	var buf bytes.Buffer
+	w, err := NewTrajectoryWriter(&buf, format, cfg.Seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want [][]float64
+	err = UpdateBoardStream(board, 3, p, func(gen int, b GameBoard) error {
+		want = append(want, freshDensities(densityOf(p), b.cells, b.width, p))
+		return w.WriteBoard(b)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	return &buf, want
+}
+
+func TestTrajectoryDensity(t *testing.T) {
+	for _, strategy := range DensityStrategies() {
+		p := testConfig().Params()
+		p.Strategy = strategy
+
+		buf, want := streamedTrajectory(t, "binary", p)
+		trajectory, err := ReadTrajectory(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for g, frame := range trajectory.Frames {
+			for k, c := range frame.Cells {
+				if c.Density != float64(float32(want[g][k])) {
+					t.Fatalf("%s binary: generation %d, cell %d has density %g, its board %g", strategy, frame.Generation, c.ID, c.Density, want[g][k])
+				}
+			}
+		}
+
+		buf, want = streamedTrajectory(t, "csv", p)
+		r := csv.NewReader(buf)
+		r.Comment = '#'
+		records, err := r.ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		row := 1 // after the header
+		for g := range want {
+			for k := range want[g] {
+				density, err := strconv.ParseFloat(records[row][4], 64)
+				if err != nil || density != want[g][k] {
+					t.Fatalf("%s csv: generation %d, row %v has density %s, its board %g", strategy, g, records[row], records[row][4], want[g][k])
+				}
+				row++
+			}
+		}
+		if row != len(records) {
+			t.Errorf("%s csv: %d rows, %d cells written", strategy, len(records)-1, row-1)
+		}
+	}
+}
@@ -0,0 +1,668 @@
+package engine
+
+import (
+	"math"
+	"math/rand"
+)
+
+type TwoClusterBoard struct {
+	cells       [][]Cell
+	width       float64
+	totalsignal int
+	rng         *rand.Rand
+	generation  int
+	births      int // cells born in the update that made the board
+	deaths      int // cells that died in the update that made the board
+	nextID      int // the last id given to a cell, source or sink
+}
+
+// Sources returns the source cells; the slice is shared with the board and must not be modified
+func (board TwoClusterBoard) Sources() []Cell { return board.cells[0] }
+
+// Sinks returns the sink cells; the slice is shared with the board and must not be modified
+func (board TwoClusterBoard) Sinks() []Cell { return board.cells[1] }
+
+// Width returns the width (and height) of the board
+func (board TwoClusterBoard) Width() float64 { return board.width }
+
+// TotalSignal returns the signal kept by the source cluster for the next update
+func (board TwoClusterBoard) TotalSignal() int { return board.totalsignal }
+
+// Generation returns the number of updates between the initial board and board
+func (board TwoClusterBoard) Generation() int { return board.generation }
+
+// Births returns the number of cells, sources and sinks, born in the update that made board
+func (board TwoClusterBoard) Births() int { return board.births }
+
+// Deaths returns the number of cells, sources and sinks, that died in the update that made board
+func (board TwoClusterBoard) Deaths() int { return board.deaths }
+
+/*
+	GenerateCell takes centerX, centerY, radius, cellType as inputs, and returns a randomly generated
+	cell with the designated cellType and within the radius around the point (centerX, centerY).
+*/
+
+func (board TwoClusterBoard) GenerateCell(centerX, centerY, radius float64, cellType string) Cell {
+	a := board.rng.Float64() * 2.0 * math.Pi
+	r := (2.0*board.rng.Float64() - 1.0) * radius
+
+	var newCell Cell
+	newCell.x = r*math.Cos(a) + centerX
+	newCell.y = r*math.Sin(a) + centerY
+
+	/*
+		Set boundary for source and sink
+		sources are allowed to grow on the left side of the board
+		sinks are only allowed to grow on the right side of the board
+	*/
+
+	if cellType == "source" {
+		newCell.celltype = 1
+		if newCell.x < 0.0 {
+			newCell.x = 0.0
+		} else if newCell.x > board.width/2 {
+			newCell.x = board.width / 2
+		} else if newCell.y < 0.0 {
+			newCell.y = 0.0
+		} else if newCell.y > board.width {
+			newCell.y = board.width
+		}
+	} else if cellType == "sink" {
+		newCell.celltype = 2
+		if newCell.x < board.width/2 {
+			newCell.x = board.width / 2
+		} else if newCell.x > board.width {
+			newCell.x = board.width
+		} else if newCell.y < 0.0 {
+			newCell.y = 0.0
+		} else if newCell.y > board.width {
+			newCell.y = board.width
+		}
+	}
+	return newCell
+}
+
+/*
+InitializeBoard takes numCells, birthRadius, the width of TwoClusterBoard and rng as inputs,
+and returns an initialized TwoClusterBoard with a total amount of numCells on the initial
+TwoClusterBoard, and source cells are on the left center of the TwoClusterBoard within
+the birthRadius, and sink cells are on the right centre of the TwoClusterBoard within the
+birthRadius. Every random choice the board makes from then on is drawn from rng.
+*/
+
+func InitializeTwoClusterBoard(numCells int, birthRadius, width float64, rng *rand.Rand) TwoClusterBoard {
+	/*
+		Make an initial TwoClusterBoard
+	*/
+	var board TwoClusterBoard
+	board.cells = make([][]Cell, 2)
+	board.width = width
+	board.rng = rng
+
+	/*
+		Randomly generate numCells cells in board
+	*/
+	for i := 0; i <= numCells-1; i++ {
+		cellType := board.rng.Intn(2)
+		if cellType == 0 { // generate source
+			source := board.GenerateCell(board.width/4, board.width/2, birthRadius, "source")
+			board.nextID++
+			source.id = board.nextID
+			source.founder = source.id
+			board.cells[0] = append(board.cells[0], source)
+		} else if cellType == 1 { // generate sink
+			sink := board.GenerateCell(3*board.width/4, board.width/2, birthRadius, "sink")
+			board.nextID++
+			sink.id = board.nextID
+			sink.founder = sink.id
+			board.cells[1] = append(board.cells[1], sink)
+		}
+	}
+
+	/*
+		Update the totalsignal, which is calculated based on the number
+		of source cells on the board
+	*/
+
+	board.totalsignal = len(board.cells[0])
+
+	return board
+}
+
+/*
+	UpdateBoard takes an initialBoard, numGens and the update Params as inputs, and
+	returns a collection of TwoClusterBoard during the update. The density of the
+	sources and of the sinks is measured separately, by the DensityEstimator named by
+	p.Strategy; with CountDensity the number of neighbours is then turned into a growth
+	score.
+*/
+
+func (initialBoard TwoClusterBoard) UpdateBoard(numGens int, p Params) []TwoClusterBoard {
+	boards := make([]TwoClusterBoard, 0)
+	initialBoard.UpdateBoardStream(numGens, p, func(gen int, board TwoClusterBoard) error {
+		boards = append(boards, board)
+		return nil
+	})
+	return boards
+}
+
+/*
+	UpdateBoardStream updates initialBoard numGens times like UpdateBoard, but hands
+	every board, starting with initialBoard, to fn as soon as it is made instead of
+	keeping it. gen is the Generation of the board, which carries on from initialBoard's
+	when it was resumed from a checkpoint. Every board fn is given has the density of its
+	own sources and sinks, the one its update goes by. It stops at, and returns, the first
+	error fn returns.
+*/
+
+func (initialBoard TwoClusterBoard) UpdateBoardStream(numGens int, p Params, fn func(gen int, board TwoClusterBoard) error) error {
+	board := initialBoard.measured(p)
+	for i := 0; i <= numGens; i++ {
+		if i > 0 {
+			board = board.advance(p).measured(p)
+		}
+		if err := fn(board.generation, board); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+	UpdateOneBoard takes a currentBoard and the update Params as inputs, and returns
+	an updated board
+*/
+
+func (currentBoard TwoClusterBoard) UpdateOneBoard(p Params) TwoClusterBoard {
+	return currentBoard.measured(p).advance(p)
+}
+
+/*
+	measured returns board with the density of its sources and of its sinks set by the
+	DensityEstimator of p, each cluster on its own, on copies of its cells so that a
+	board already handed out keeps the densities it was given. If the estimator is a
+	GrowthScorer, as CountDensity is, the densities are then turned into growth scores.
+*/
+
+func (board TwoClusterBoard) measured(p Params) TwoClusterBoard {
+	estimator := densityOf(p)
+	clusters := make([][]Cell, len(board.cells))
+	for i := range board.cells {
+		clusters[i] = estimator.Density(append([]Cell(nil), board.cells[i]...), board.width, p)
+		if scorer, ok := estimator.(GrowthScorer); ok {
+			clusters[i] = scorer.GrowthScores(clusters[i])
+		}
+	}
+	board.cells = clusters
+	return board
+}
+
+// advance makes the board of the next generation from currentBoard, whose densities are measured
+func (currentBoard TwoClusterBoard) advance(p Params) TwoClusterBoard {
+	searchRadius, birthRadius, deathRadius := p.SearchRadius, p.BirthRadius, p.DeathRadius
+	birthrate, deathrate := p.BirthRate, p.DeathRate
+
+	/*
+		Make a new board
+	*/
+
+	var newboard1 TwoClusterBoard
+	newboard1 = currentBoard.CopyBoard()
+	newboard1.generation++
+	for i := range newboard1.cells {
+		ageCells(newboard1.cells[i])
+	}
+
+	/*
+		Sort cells in newboard by density
+	*/
+
+	for i := range newboard1.cells {
+		newboard1.cells[i] = newboard1.SortingDensity(i)
+	}
+
+	/*
+		In currentboard cells, and caluclate the birthkey and deathkey
+		according to birthrate and deathrate. In the sorted []Cell (from most dense
+		to least dense), cells after the birthkey need to perform born and move
+		function, and cells before the deathkey cell need to perform death function.
+	*/
+
+	for i := range newboard1.cells {
+		birthkey := int(float64(len(currentBoard.cells[i])) * (1 - birthrate))
+		deathkey := int(float64(len(currentBoard.cells[i])) * deathrate)
+		parents := len(newboard1.cells[i])
+		if p.BirthMode == "chunked" {
+			newboard1.cells[i] = newboard1.bornParallel(NewSpatialIndex(p.Index, newboard1.cells[i], newboard1.width, searchRadius), birthRadius, searchRadius, birthkey, i, p.Workers)
+		} else {
+			newboard1.cells[i] = newboard1.born(NewSpatialIndex(p.Index, newboard1.cells[i], newboard1.width, searchRadius), birthRadius, searchRadius, birthkey, i)
+		}
+		newboard1.births += len(newboard1.cells[i]) - parents
+		numberCells(newboard1.cells[i], parents, &newboard1.nextID)
+		newboard1.cells[i] = newboard1.move(NewSpatialIndex(p.Index, newboard1.cells[i], newboard1.width, searchRadius), birthkey, deathkey, i, searchRadius, p.Workers)
+		if len(newboard1.cells[i]) < 5 {
+			break
+		} else if len(newboard1.cells[i]) >= 5 {
+			moved := len(newboard1.cells[i])
+			newboard1.cells[i] = newboard1.SortingDensity(i)
+			if p.DeathMode != "senescence" {
+				newboard1.cells[i] = survivors(newboard1.cells[i], deathRadius, NewSpatialIndex(p.Index, newboard1.cells[i], newboard1.width, searchRadius))
+			}
+			if p.DeathMode == "senescence" || p.DeathMode == "both" {
+				newboard1.cells[i] = senescence(newboard1.cells[i], newboard1.rng)
+			}
+			newboard1.deaths += moved - len(newboard1.cells[i])
+		}
+	}
+
+	/*
+		Sort the source cells by their X positions, which can be used to estimate if the boundary
+		of two clusters are close enough for the transmission of the signal by calcultating the
+		distance between the most left cell in the source cluster and the most right cell in the
+		sink cluster.
+	*/
+
+	newboard1.cells[0] = newboard1.SortingXPosition(0)
+
+	lastnode := len(newboard1.cells[0]) - 1
+
+	newboard1.cells[1] = newboard1.SortingXPosition(1)
+
+	if lastnode >= 0 && len(newboard1.cells[1]) > 0 && CalculateDistance(newboard1.cells[1][0], newboard1.cells[0][lastnode]) < 100.0 {
+
+		/*
+			The totalsignal availiable is calculated by adding the totalsignal with the cell born
+			at each update. Then, 9/10 of them would be used in the signal transmission, and then
+			1/10 would remained for the next update.
+		*/
+
+		intitalSourceNumber := len(currentBoard.cells[0])
+		finalSourceNumber := len(newboard1.cells[0])
+		newboard1.totalsignal = finalSourceNumber - intitalSourceNumber + newboard1.totalsignal
+		availiableSignal := 9 * newboard1.totalsignal / 10
+		newboard1.totalsignal = newboard1.totalsignal / 10
+
+		/*
+			The maximum amount of signal a sink can receive from the source cluster is 3. According
+			to the sorted X position, the sink cell at the most left will be added with signal. If
+			the sink cell reaches a signal level of 3, we proceed to the second most left sink and so
+			on.
+		*/
+
+		for i := range newboard1.cells[1] {
+			if newboard1.cells[1][i].signalLevel == 3 {
+				continue
+			} else if newboard1.cells[1][i].signalLevel < 3 {
+				if newboard1.cells[1][i].signalLevel+availiableSignal >= 3 {
+					signaladded := newboard1.rng.Intn(3-newboard1.cells[1][i].signalLevel) + 1
+					newboard1.cells[1][i].signalLevel = newboard1.cells[1][i].signalLevel + signaladded
+					availiableSignal = availiableSignal - signaladded
+				} else if availiableSignal+newboard1.cells[1][i].signalLevel < 3 {
+					newboard1.cells[1][i].signalLevel += availiableSignal
+					availiableSignal = 0
+				}
+			} else if availiableSignal == 0 {
+				break
+			}
+		}
+
+	}
+
+	return newboard1
+}
+
+/*
+	CopyBoard takes a TwoClusterBoard and returns a copied TwoClusterBoard
+*/
+
+func (board TwoClusterBoard) CopyBoard() TwoClusterBoard {
+
+	/*
+		Make a new board
+	*/
+	var newboard TwoClusterBoard
+
+	/*
+		Copy cells information from currentboard to newboard1 in a new store space
+	*/
+
+	newboard.cells = make([][]Cell, 2)
+	for i := range board.cells {
+		cells := make([]Cell, 0)
+		for j := range board.cells[i] {
+			cells = append(cells, board.cells[i][j])
+		}
+		newboard.cells[i] = append(newboard.cells[i], cells...)
+	}
+
+	/*
+		Copy properties of board
+	*/
+
+	newboard.width = board.width
+	newboard.totalsignal = board.totalsignal
+	newboard.rng = board.rng
+	newboard.generation = board.generation
+	newboard.nextID = board.nextID
+
+	return newboard
+}
+
+/*
+	Death takes a TwoClusterBoard, deathRadius, and k as inputs, and returns
+	a slice of Cell after death representing board.cells[k]
+*/
+
+func (board TwoClusterBoard) Death(deathRadius float64, k int) []Cell {
+
+	/*
+		If the distance between two cells is smaller than deathRadius,
+		and the cell with greater density dies
+	*/
+
+	board.cells[k] = board.SortingDensity(k)
+	return survivors(board.cells[k], deathRadius, NewGrid(board.cells[k], board.width, deathRadius))
+}
+
+/*
+	CountDensity takes a list of Cell, searchRadius, and k as input, and returns a slice
+	of Cell, board.cells[k], with updated density in each Cell.
+*/
+
+func (board TwoClusterBoard) CountDensity(k int, searchRadius float64) []Cell {
+	return board.countDensity(NewGrid(board.cells[k], board.width, searchRadius), k, searchRadius, 1)
+}
+
+/*
+	countDensity is CountDensity with the radius queries answered by index, shared by
+	workers goroutines
+*/
+
+func (board TwoClusterBoard) countDensity(index SpatialIndex, k int, searchRadius float64, workers int) []Cell {
+	return growthScores(countDensity(board.cells[k], searchRadius, index, workers))
+}
+
+/*
+	growthScores replaces the number of neighbours counted as the density of each of
+	cells by its growth score, according to the formula: score = e^density/((1+e^density)^2),
+	derivative of the logistic growth curve
+*/
+
+func growthScores(cells []Cell) []Cell {
+	for i := range cells {
+		d := float64(cells[i].density) - 500.0
+		growthScore := math.Exp(d) / ((1 + math.Exp(d)) * (1 + math.Exp(d)))
+		cells[i].density = growthScore
+	}
+	return cells
+}
+
+/*
+	SortingDensity takes board and i as inputs, and returns a sorted []Cell from highest
+	density to lowest density using the heap structure
+*/
+
+func (board TwoClusterBoard) SortingDensity(i int) []Cell {
+	cells := board.cells[i]
+
+	/*
+		Make a max heap
+		i = parent node
+		a = child index
+	*/
+
+	for j := len(cells)/2 - 1; j >= 0; j-- { //range from largest parent node to root
+		/*
+			Heapify down
+		*/
+		i := j
+		for 2*i+1 <= len(cells)-1 { // while cells[i] still have child, heapify down
+			a := 2*i + 1               // left child
+			if 2*i+2 <= len(cells)-1 { // if right child exists
+				if cells[2*i+2].density < cells[2*i+1].density {
+					a = 2*i + 2
+				}
+			}
+			if cells[i].density > cells[a].density {
+				cells[i], cells[a] = cells[a], cells[i] // if parent < child, heapify down
+				i = a
+			} else {
+				break
+			}
+		}
+	}
+
+	/*
+		Sorting
+	*/
+
+	length := len(cells) - 1 // the last node's index
+	for length > 0 {         // while there's still nodes in heaps
+		cells[0], cells[length] = cells[length], cells[0] // swap root with the last node
+		length--
+		i := 0
+		for 2*i+1 <= length { // while cells[i] still have child, heapify down
+			a := 2*i + 1         // left child
+			if 2*i+2 <= length { // if right child exists
+				if cells[2*i+2].density < cells[2*i+1].density {
+					a = 2*i + 2
+				}
+			}
+			if cells[i].density > cells[a].density {
+				cells[i], cells[a] = cells[a], cells[i] // if parent < child, swap
+				i = a
+			} else {
+				break // if no node to swap, break loop
+			}
+		}
+	}
+	return cells
+}
+
+/*
+	Born takes a board, birthRadius, searchRadius, birthkey, and i as inputs,
+	and returns an updated slice of Cell of board.cells[l] with the newborn
+	Cell.
+*/
+
+func (board TwoClusterBoard) Born(birthRadius, searchRadius float64, birthkey int, l int) []Cell {
+	return board.born(NewGrid(board.cells[l], board.width, searchRadius), birthRadius, searchRadius, birthkey, l)
+}
+
+/*
+	born is Born with the radius queries answered by index, which is kept up to date
+	with the newborn cells
+*/
+
+func (board TwoClusterBoard) born(index SpatialIndex, birthRadius, searchRadius float64, birthkey int, l int) []Cell {
+	length := len(board.cells[l])
+
+	/*
+		Cells that are after the birthkey in the sorted density slice will have
+		a new Cell born within the birthRadius of the current Cell.
+	*/
+
+	for i := birthkey; i <= length-1; i++ {
+		newborn := board.offspring(index, board.cells[l][i], len(board.cells[l]), birthRadius, searchRadius, l)
+		board.cells[l] = append(board.cells[l], newborn)
+		index.Insert(newborn)
+	}
+	return board.cells[l]
+}
+
+/*
+	bornParallel is born shared by workers goroutines. Every chunk of parents draws from
+	its own stream (see chunkRand) and counts the density of the candidates against the
+	cells as they were before any birth this generation, so the newborns do not depend
+	on the number of workers or the order the chunks run in.
+*/
+
+func (board TwoClusterBoard) bornParallel(index SpatialIndex, birthRadius, searchRadius float64, birthkey, l, workers int) []Cell {
+	length := len(board.cells[l])
+	seed := board.rng.Int63()
+	parents := length - birthkey
+	if parents < 0 {
+		parents = 0
+	}
+	newborns := make([]Cell, parents)
+	forChunks(parents, workers, func(chunk, lo, hi int) {
+		local := board
+		local.rng = chunkRand(seed, chunk)
+		for i := lo; i < hi; i++ {
+			newborns[i] = local.offspring(index, board.cells[l][birthkey+i], length, birthRadius, searchRadius, l)
+		}
+	})
+	return append(board.cells[l], newborns...)
+}
+
+/*
+	offspring returns the cell that parent gives birth to in cluster l: the least dense
+	of 10 random candidates within birthRadius
+*/
+
+func (board TwoClusterBoard) offspring(index SpatialIndex, parent Cell, min int, birthRadius, searchRadius float64, l int) Cell {
+	var cellType string
+	if l == 0 {
+		cellType = "source"
+	} else if l == 1 {
+		cellType = "sink"
+	}
+
+	/*
+		Randomly generate 10 cells and choose the one with least density
+	*/
+	a := make([]Cell, 10)
+	choice := 0
+	for j := 0; j <= 9; j++ {
+		a[j] = board.GenerateCell(parent.x, parent.y, birthRadius, cellType)
+		a[j].density += float64(index.Count(a[j].x, a[j].y, searchRadius))
+		if a[j].density < float64(min) {
+			choice = j
+		}
+	}
+	a[choice].descend(parent, board.generation)
+	return a[choice]
+}
+
+/*
+	Move takes a board, birthkey, deathkey, m, and searchRadius as inputs,
+	and returns a slice of Cell of board.cells[m] after performing the movement
+	among cells
+*/
+
+func (board TwoClusterBoard) Move(birthkey, deathkey, m int, searchRadius float64) []Cell {
+	return board.move(NewGrid(board.cells[m], board.width, searchRadius), birthkey, deathkey, m, searchRadius, 1)
+}
+
+/*
+	move is Move with the radius queries answered by index, shared by workers goroutines
+*/
+
+func (board TwoClusterBoard) move(index SpatialIndex, birthkey, deathkey, m int, searchRadius float64, workers int) []Cell {
+	newcells := make([]Cell, 0)
+	for i := 0; i <= len(board.cells[m])-1; i++ {
+		newcells = append(newcells, board.cells[m][i])
+	}
+	forChunks(len(board.cells[m]), workers, func(_, lo, hi int) {
+		board.moveRange(newcells, index, lo, hi, birthkey, deathkey, m, searchRadius)
+	})
+	return newcells
+}
+
+/*
+	moveRange moves the cells [lo, hi) of newcells by the forces of board.cells[m]
+*/
+
+func (board TwoClusterBoard) moveRange(newcells []Cell, index SpatialIndex, lo, hi, birthkey, deathkey, m int, searchRadius float64) {
+	var near []int
+	for i := lo; i < hi; i++ {
+		/*
+			For the cells that is not newborn but after the birthkey, if two cells are within the
+			searchRadius, cells attract each other. For the cells that is before the deathkey,
+			if two cells are within the searchRadius, cells repel each other
+		*/
+
+		near = index.Neighbours(board.cells[m][i].x, board.cells[m][i].y, searchRadius, near[:0])
+		xmove, ymove := netForce(board.cells[m], i, near, birthkey, (len(board.cells[m])+birthkey)/2, deathkey)
+
+		/*
+			Calculate the net force
+			Also, set the boundary for cells after the move function
+		*/
+
+		newcells[i].x += xmove
+		newcells[i].y += ymove
+		if newcells[i].x < 0.0 {
+			newcells[i].x = 0.0
+		}
+		if newcells[i].x > board.width {
+			newcells[i].x = board.width
+		}
+		if newcells[i].y < 0.0 {
+			newcells[i].y = 0.0
+		}
+		if newcells[i].y > board.width {
+			newcells[i].y = board.width
+		}
+	}
+}
+
+/*
+	SortingXPosition takes a board and i as inputs, and returns a sorted slice of Cells
+	after ranking the cells in board.cells[i] according to their x value with the order
+	from lowest x position to highest x position
+*/
+
+func (board TwoClusterBoard) SortingXPosition(i int) []Cell {
+	cells := board.cells[i]
+	/*
+		Make a min heap
+		i = parent node
+		a = child index
+	*/
+
+	for j := len(cells)/2 - 1; j >= 0; j-- { // range from largest parent node to root
+		// Heapify down
+		i := j
+		for 2*i+1 <= len(cells)-1 { // while cells[i] still have child, heapify down
+			a := 2*i + 1               // left child
+			if 2*i+2 <= len(cells)-1 { // if right child exists
+				if cells[2*i+2].x > cells[2*i+1].x {
+					a = 2*i + 2
+				}
+			}
+			if cells[i].x < cells[a].x {
+				cells[i], cells[a] = cells[a], cells[i] // if parent < child, swap
+				i = a
+			} else {
+				break
+			}
+		}
+	}
+
+	/*
+		Sorting
+	*/
+
+	length := len(cells) - 1 // the last node's index
+	for length > 0 {         // while there's still nodes in heaps
+		cells[0], cells[length] = cells[length], cells[0] // swap root with the last node
+		length--
+		i := 0
+		for 2*i+1 <= length { // while cells[i] still have child, heapify down
+			a := 2*i + 1         // left child
+			if 2*i+2 <= length { // if right child exists
+				if cells[2*i+2].x > cells[2*i+1].x {
+					a = 2*i + 2
+				}
+			}
+			if cells[i].x < cells[a].x {
+				cells[i], cells[a] = cells[a], cells[i] // if parent < child, swap
+				i = a
+			} else {
+				break //if no node to swap, break loop
+			}
+		}
+	}
+	return cells
+}
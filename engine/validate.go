@@ -0,0 +1,105 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ConfigError is one problem found in a config, with the file, line and key it came from
+type ConfigError struct {
+	File    string
+	Line    int
+	Key     string
+	Message string
+}
+
+func (e ConfigError) Error() string {
+	var b strings.Builder
+	b.WriteString(e.File)
+	if e.Line > 0 {
+		fmt.Fprintf(&b, ":%d", e.Line)
+	}
+	if e.Key != "" {
+		fmt.Fprintf(&b, ": %s", e.Key)
+	}
+	fmt.Fprintf(&b, ": %s", e.Message)
+	return b.String()
+}
+
+// ConfigErrors collects every problem found in a config so they can be reported at once
+type ConfigErrors []ConfigError
+
+func (errs ConfigErrors) Error() string {
+	lines := make([]string, len(errs))
+	for i := range errs {
+		lines[i] = errs[i].Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+/*
+	Validate checks every parameter and combination of parameters that would make a
+	simulation fail or misbehave part way through UpdateBoard, and returns all of the
+	problems as ConfigErrors, or nil if the config is usable.
+*/
+
+func (cfg SimulationConfig) Validate() error {
+	var errs ConfigErrors
+	check := func(ok bool, key, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, cfg.errorAt(key, fmt.Sprintf(format, args...)))
+		}
+	}
+
+	check(cfg.InitialCells >= 1, "initialcells", "must be at least 1, got %d", cfg.InitialCells)
+	check(cfg.NumGens >= 0, "numGens", "must not be negative, got %d", cfg.NumGens)
+	check(cfg.Width > 0, "width", "must be greater than 0, got %g", cfg.Width)
+	check(cfg.SearchRadius > 0, "searchRadius", "must be greater than 0, got %g", cfg.SearchRadius)
+	check(cfg.BirthRadius > 0, "birthRadius", "must be greater than 0, got %g", cfg.BirthRadius)
+	check(cfg.DeathRadius >= 0, "deathRadius", "must not be negative, got %g", cfg.DeathRadius)
+	check(cfg.DeathRadius < cfg.SearchRadius, "deathRadius", "must be smaller than searchRadius (%g), got %g", cfg.SearchRadius, cfg.DeathRadius)
+	check(cfg.BirthRate > 0 && cfg.BirthRate <= 1, "birthrate", "must be greater than 0 and at most 1, got %g", cfg.BirthRate)
+	check(cfg.DeathRate >= 0 && cfg.DeathRate <= 1, "deathrate", "must be between 0 and 1, got %g", cfg.DeathRate)
+	check(cfg.NumZones >= 0, "numZones", "must not be negative, got %d", cfg.NumZones)
+	check(cfg.AddMaze == 0 || cfg.AddMaze == 1, "addmaze", "must be 0 or 1, got %d", cfg.AddMaze)
+	_, knownStrategy := LookupDensity(cfg.Strategy)
+	check(knownStrategy, "strategy", "must be one of %s, got %q", strings.Join(DensityStrategies(), ", "), cfg.Strategy)
+	check(cfg.CheckpointEvery >= 0, "checkpointEvery", "must not be negative, got %d", cfg.CheckpointEvery)
+	check(cfg.Workers >= 1, "workers", "must be at least 1, got %d", cfg.Workers)
+	check(knownIndex(cfg.Index), "index", "must be one of %s, got %q", strings.Join(IndexKinds, ", "), cfg.Index)
+	check(knownKernel(cfg.Kernel), "kernel", "must be one of %s, got %q", strings.Join(KernelKinds, ", "), cfg.Kernel)
+	check(cfg.Bandwidth >= 0, "bandwidth", "must not be negative, got %g", cfg.Bandwidth)
+	check(cfg.K >= 1, "k", "must be at least 1, got %d", cfg.K)
+	check(knownDeathMode(cfg.DeathMode), "deathMode", "must be one of %s, got %q", strings.Join(DeathModes, ", "), cfg.DeathMode)
+	check(knownBirthMode(cfg.BirthMode), "birthMode", "must be one of %s, got %q", strings.Join(BirthModes, ", "), cfg.BirthMode)
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ValidateTwoCluster is Validate plus the checks that only apply to the source and sink model
+func (cfg SimulationConfig) ValidateTwoCluster() error {
+	var errs ConfigErrors
+	if err := cfg.Validate(); err != nil {
+		errs = err.(ConfigErrors)
+	}
+	if cfg.InitialCells == 1 {
+		errs = append(errs, cfg.errorAt("initialcells", fmt.Sprintf("must be at least 2 to seed a source and a sink, got %d", cfg.InitialCells)))
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// errorAt builds a ConfigError for key, pointing at where the key was set
+func (cfg SimulationConfig) errorAt(key, message string) ConfigError {
+	origin, ok := cfg.origins[key]
+	if !ok {
+		origin.file = "default"
+	}
+	return ConfigError{File: origin.file, Line: origin.line, Key: key, Message: message}
+}
@@ -0,0 +1,137 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		file  string
+		input string
+		want  ConfigErrors
+	}{
+		{
+			name:  "usable config",
+			file:  "in.txt",
+			input: "numGens: 10\nstrategy: KNN\nk: 3",
+		},
+		{
+			name:  "invalid range",
+			file:  "in.txt",
+			input: "numGens: 10\nbirthrate: 1.5",
+			want:  ConfigErrors{{File: "in.txt", Line: 2, Key: "birthrate", Message: "must be greater than 0 and at most 1, got 1.5"}},
+		},
+		{
+			name:  "several errors, each at the line it was set on",
+			file:  "in.yaml",
+			input: "width: -1\nseed: 3\nworkers: 0\nstrategy: Nearest",
+			want: ConfigErrors{
+				{File: "in.yaml", Line: 1, Key: "width", Message: "must be greater than 0, got -1"},
+				{File: "in.yaml", Line: 4, Key: "strategy", Message: `must be one of CountDensity, KNN, Kernel, Voronoi, got "Nearest"`},
+				{File: "in.yaml", Line: 3, Key: "workers", Message: "must be at least 1, got 0"},
+			},
+		},
+		{
+			name:  "combination of keys, at the later key",
+			file:  "in.txt",
+			input: "searchRadius: 5\ndeathRadius: 8",
+			want:  ConfigErrors{{File: "in.txt", Line: 2, Key: "deathRadius", Message: "must be smaller than searchRadius (5), got 8"}},
+		},
+		{
+			name:  "unknown key",
+			file:  "in.txt",
+			input: "numGens: 10\nradius: 4",
+			want:  ConfigErrors{{File: "in.txt", Line: 2, Key: "radius", Message: "unknown key"}},
+		},
+		{
+			name:  "duplicate key",
+			file:  "in.yaml",
+			input: "numGens: 10\nseed: 1\nnumGens: 20",
+			want:  ConfigErrors{{File: "in.yaml", Line: 3, Key: "numGens", Message: "already set on line 1"}},
+		},
+		{
+			name:  "duplicate JSON key",
+			file:  "in.json",
+			input: "{\n  \"k\": 2,\n  \"k\": 3\n}",
+			want:  ConfigErrors{{File: "in.json", Line: 3, Key: "k", Message: "already set on line 2"}},
+		},
+		{
+			name:  "JSON line",
+			file:  "in.json",
+			input: "{\n  \"numGens\": 10,\n  \"deathMode\": \"old age\"\n}",
+			want:  ConfigErrors{{File: "in.json", Line: 3, Key: "deathMode", Message: `must be one of crowding, senescence, both, got "old age"`}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var cfg SimulationConfig
+			var err error
+			if tc.file == "in.json" {
+				cfg, err = ParseJSONConfig(tc.file, []byte(tc.input))
+			} else {
+				cfg, err = ParseTextConfig(tc.file, []byte(tc.input))
+			}
+			if err == nil {
+				err = cfg.Validate()
+			}
+			if tc.want == nil {
+				if err != nil {
+					t.Errorf("got %v, want no errors", err)
+				}
+				return
+			}
+			if got, ok := err.(ConfigErrors); !ok || !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got\n%v\nwant\n%v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestValidateOrigins(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.BirthRate = 0
+	if err := cfg.Override("workers", "0"); err != nil {
+		t.Fatal(err)
+	}
+	want := ConfigErrors{
+		{File: "default", Key: "birthrate", Message: "must be greater than 0 and at most 1, got 0"},
+		{File: "command line", Key: "workers", Message: "must be at least 1, got 0"},
+	}
+	if err := cfg.Validate(); !reflect.DeepEqual(err, want) {
+		t.Errorf("got\n%v\nwant\n%v", err, want)
+	}
+}
+
+func TestValidateTwoCluster(t *testing.T) {
+	cfg, err := ParseTextConfig("in.txt", []byte("initialcells: 1\nnumZones: -1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Validate(); err == nil || len(err.(ConfigErrors)) != 1 {
+		t.Errorf("one cluster: got %v, want only numZones", err)
+	}
+	want := ConfigErrors{
+		{File: "in.txt", Line: 2, Key: "numZones", Message: "must not be negative, got -1"},
+		{File: "in.txt", Line: 1, Key: "initialcells", Message: "must be at least 2 to seed a source and a sink, got 1"},
+	}
+	if err := cfg.ValidateTwoCluster(); !reflect.DeepEqual(err, want) {
+		t.Errorf("got\n%v\nwant\n%v", err, want)
+	}
+}
+
+func TestConfigErrorText(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want string
+	}{
+		{ConfigError{File: "in.txt", Line: 4, Key: "width", Message: "must be greater than 0, got 0"}, "in.txt:4: width: must be greater than 0, got 0"},
+		{ConfigError{File: "command line", Key: "k", Message: "must be at least 1, got 0"}, "command line: k: must be at least 1, got 0"},
+		{ConfigError{File: "in.json", Line: 2, Message: "invalid character"}, "in.json:2: invalid character"},
+		{ConfigErrors{{File: "a", Line: 1, Key: "x", Message: "m"}, {File: "b", Line: 2, Key: "y", Message: "n"}}, "a:1: x: m\nb:2: y: n"},
+	} {
+		if got := tc.err.Error(); got != tc.want {
+			t.Errorf("got %q, want %q", got, tc.want)
+		}
+	}
+}
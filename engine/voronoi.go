@@ -0,0 +1,620 @@
+package engine
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+)
+
+// VPoint is a vertex of a Voronoi cell
+type VPoint struct {
+	x float64
+	y float64
+}
+
+/*
+	VEdge is a side of the Voronoi cell of a cell, from start to end going counter
+	clockwise around it. cell is the id of the cell on the other side of the edge, or 0
+	where the edge lies on the border of the board.
+*/
+
+type VEdge struct {
+	start *VPoint
+	end   *VPoint
+	cell  int
+}
+
+/*
+	VSite is a place of the Voronoi diagram, holding every cell at exactly that place,
+	so that cells on top of each other share one Voronoi cell instead of making the
+	diagram degenerate. index is the position of the site in the sites of the diagram.
+*/
+
+type VSite struct {
+	x     float64
+	y     float64
+	cells []int
+	index int
+}
+
+/*
+	VParabola is a node of the beach line of Fortune's algorithm. Leaves are the arcs,
+	each the parabola of a site, with the circle event that would close it; inner nodes
+	are the breakpoints between the last arc on their left and the first on their right.
+*/
+
+type VParabola struct {
+	isLeaf bool
+	site   *VSite
+	cEvent *VEvent
+	parent *VParabola
+	left   *VParabola
+	right  *VParabola
+}
+
+/*
+	VEvent is an event of the sweep at height y: a site event when site is set, or the
+	circle event that closes the arc arch, whose circle is centred at x.
+*/
+
+type VEvent struct {
+	x     float64
+	y     float64
+	site  *VSite
+	arch  *VParabola
+	index int // position in the event queue, -1 once it is out of it
+}
+
+/*
+	Voronoi sets the density of every cell of the board from the area of its Voronoi cell,
+	clipped to the board: a cell with more room is less dense. Cells at the same place
+	share their Voronoi cell equally, and the density of a cell whose share has area a is
+	1/(a+0.1). A cell alone on the board has a density of width*width instead, as it
+	always has, so that the runs that end with one cell repeat. Each cell keeps the edges
+	of its Voronoi cell for this generation only. It returns the board with its cells in
+	the same order.
+*/
+
+func Voronoi(board *GameBoard) GameBoard {
+	sites, polygons := VoronoiPolygons(board.cells, board.width)
+	for _, site := range sites {
+		polygon := newPolygon(board.cells, sites, polygons[site.index])
+		area := polygon.Area() / float64(len(site.cells))
+		edges := polygon.edges()
+		for _, i := range site.cells {
+			(*board).cells[i].density = 1 / (area + 0.1)
+			(*board).cells[i].edges = edges
+		}
+	}
+	if len(board.cells) == 1 {
+		(*board).cells[0].density = board.width * board.width
+	}
+	return *board
+}
+
+// GetArea returns the area inside the Voronoi edges of c, the area of c.Polygon()
+func GetArea(c Cell) float64 {
+	return c.Polygon().Area()
+}
+
+/*
+	cellPolygon is a convex polygon with its points going counter clockwise. Side k
+	goes from point k to point k+1 (and the last side back to point 0), and lies on the
+	bisector between the polygon's site and site sides[k], or on the border of the
+	board where sides[k] is -1.
+*/
+
+type cellPolygon struct {
+	points []VPoint
+	sides  []int
+}
+
+// boardPolygon is the whole board, a square of side width
+func boardPolygon(width float64) cellPolygon {
+	return cellPolygon{
+		points: []VPoint{{0, 0}, {width, 0}, {width, width}, {0, width}},
+		sides:  []int{-1, -1, -1, -1},
+	}
+}
+
+/*
+	clip returns the part of polygon closer to site s than to site t, the half plane
+	cut off by their bisector, whose side is labelled t.
+*/
+
+func (polygon cellPolygon) clip(s, t *VSite) cellPolygon {
+	nx, ny := t.x-s.x, t.y-s.y
+	mx, my := (s.x+t.x)/2, (s.y+t.y)/2
+	outside := func(p VPoint) float64 { return (p.x-mx)*nx + (p.y-my)*ny }
+
+	var clipped cellPolygon
+	n := len(polygon.points)
+	for k := 0; k < n; k++ {
+		cur, next := polygon.points[k], polygon.points[(k+1)%n]
+		fc, fn := outside(cur), outside(next)
+		if fc <= 0 {
+			clipped.points = append(clipped.points, cur)
+			clipped.sides = append(clipped.sides, polygon.sides[k])
+		}
+		if (fc <= 0) != (fn <= 0) {
+			r := fc / (fc - fn)
+			cross := VPoint{cur.x + r*(next.x-cur.x), cur.y + r*(next.y-cur.y)}
+			clipped.points = append(clipped.points, cross)
+			if fc <= 0 {
+				// the side from cross on runs along the bisector
+				clipped.sides = append(clipped.sides, t.index)
+			} else {
+				clipped.sides = append(clipped.sides, polygon.sides[k])
+			}
+		}
+	}
+	return clipped
+}
+
+// area returns the area of the polygon by the shoelace formula
+func (polygon cellPolygon) area() float64 {
+	area := 0.0
+	n := len(polygon.points)
+	for k := 0; k < n; k++ {
+		p, q := polygon.points[k], polygon.points[(k+1)%n]
+		area += p.x*q.y - q.x*p.y
+	}
+	return area / 2
+}
+
+// voronoiTolerance is how far, relative to the area of the board, the areas of the cells may sum away from it
+const voronoiTolerance = 1e-9
+
+/*
+	VoronoiPolygons returns the sites of cells, each the cells at one place, and the
+	Voronoi cell of each site clipped to the board of the given width, indexed like the
+	sites. The neighbours of each site are found with Fortune's algorithm, and its cell
+	is the board cut by the bisector with each of them, so that every cell is a convex
+	polygon inside the board and together they cover it. Should the areas not add up to
+	the board, as rounding could make happen on extreme inputs, the cells are cut again
+	from the nearest sites found by a search of the board instead.
+*/
+
+func VoronoiPolygons(cells []Cell, width float64) ([]*VSite, []cellPolygon) {
+	sites := siteList(cells)
+	if len(sites) == 0 {
+		return sites, nil
+	}
+	polygons := sweptPolygons(sites, width)
+	total := 0.0
+	for i := range polygons {
+		total += polygons[i].area()
+	}
+	if math.Abs(total-width*width) > voronoiTolerance*width*width {
+		nearestPolygons(sites, polygons, width)
+	}
+	return sites, polygons
+}
+
+// sweptPolygons cuts the cell of every site from the board by the bisectors with the neighbours GetNeighbours finds
+func sweptPolygons(sites []*VSite, width float64) []cellPolygon {
+	neighbours := make([][]int, len(sites))
+	for _, pair := range GetNeighbours(sites) {
+		s, t := pair[0].index, pair[1].index
+		neighbours[s] = append(neighbours[s], t)
+		neighbours[t] = append(neighbours[t], s)
+	}
+
+	polygons := make([]cellPolygon, len(sites))
+	for i, site := range sites {
+		polygons[i] = boardPolygon(width)
+		sort.Ints(neighbours[i])
+		for k, t := range neighbours[i] {
+			if t != i && (k == 0 || t != neighbours[i][k-1]) {
+				polygons[i] = polygons[i].clip(site, sites[t])
+			}
+		}
+	}
+	return polygons
+}
+
+// siteList returns the sites of cells, in the order of the first cell at each
+func siteList(cells []Cell) []*VSite {
+	sites := make([]*VSite, 0, len(cells))
+	at := make(map[VPoint]*VSite, len(cells))
+	for i := range cells {
+		p := VPoint{cells[i].x, cells[i].y}
+		if site, ok := at[p]; ok {
+			site.cells = append(site.cells, i)
+			continue
+		}
+		site := &VSite{x: p.x, y: p.y, cells: []int{i}, index: len(sites)}
+		at[p] = site
+		sites = append(sites, site)
+	}
+	return sites
+}
+
+/*
+	nearestPolygons cuts the cell of every site from the board by the bisectors with
+	the other sites, nearest first, within a radius that doubles until every site
+	further away is more than twice as far as any point of the cell, and so cannot cut
+	it.
+*/
+
+func nearestPolygons(sites []*VSite, polygons []cellPolygon, width float64) {
+	points := make([]Cell, len(sites))
+	for i, site := range sites {
+		points[i] = NewCell(site.x, site.y)
+	}
+	start := width / math.Sqrt(float64(len(sites)))
+	grid := NewGrid(points, width, start)
+
+	var near []int
+	for i, site := range sites {
+		polygon := boardPolygon(width)
+		cut := make(map[int]bool)
+		for r := start; ; r *= 2 {
+			near = grid.Neighbours(site.x, site.y, r, near[:0])
+			sort.Slice(near, func(a, b int) bool {
+				return math.Hypot(sites[near[a]].x-site.x, sites[near[a]].y-site.y) < math.Hypot(sites[near[b]].x-site.x, sites[near[b]].y-site.y)
+			})
+			for _, t := range near {
+				if t != i && !cut[t] {
+					polygon = polygon.clip(site, sites[t])
+					cut[t] = true
+				}
+			}
+			reach := 0.0
+			for _, p := range polygon.points {
+				reach = math.Max(reach, math.Hypot(p.x-site.x, p.y-site.y))
+			}
+			if 2*reach <= r || len(near) == len(sites) {
+				break
+			}
+		}
+		polygons[i] = polygon
+	}
+}
+
+/*
+	GetNeighbours sweeps the sites from the top of the board down with Fortune's
+	algorithm, and returns every pair of sites whose Voronoi cells share an edge; a pair
+	may come more than once, and sites on a common circle may also be paired with a
+	site they only share a corner with. Sites at the same height are swept from left to
+	right. When an arc is split or closed, the circle event it was waiting for is taken
+	out of the queue, so every event popped is one that happens.
+*/
+
+func GetNeighbours(sites []*VSite) [][2]*VSite {
+	pairs := make([][2]*VSite, 0, 3*len(sites))
+	var tree *VParabola // the beach line
+	queue := make(eventQueue, len(sites))
+	for i, site := range sites {
+		queue[i] = &VEvent{x: site.x, y: site.y, site: site, index: i}
+	}
+	heap.Init(&queue)
+
+	for queue.Len() != 0 {
+		e := heap.Pop(&queue).(*VEvent)
+		if e.site != nil {
+			pairs = insertParabola(&tree, e, &queue, pairs)
+		} else {
+			pairs = removeParabola(e, &queue, pairs)
+		}
+	}
+	return pairs
+}
+
+/*
+	insertParabola adds the arc of the site of e to the beach line, splitting the arc
+	above it, and pairs the two sites.
+*/
+
+func insertParabola(tree **VParabola, e *VEvent, queue *eventQueue, pairs [][2]*VSite) [][2]*VSite {
+	site := e.site
+	if *tree == nil {
+		*tree = initiationParabola1(site)
+		return pairs
+	}
+
+	p := getCorrespondingPar(*tree, site.x, site.y)
+	pairs = append(pairs, [2]*VSite{p.site, site})
+	queue.cancelCircle(p) // the arc is split, so its circle event will not happen
+
+	// sites level with the first one: the arc above is a vertical ray, so the new arc goes beside it
+	if p.site.y == site.y {
+		left, right := initiationParabola1(p.site), initiationParabola1(site)
+		if site.x < p.site.x {
+			left, right = right, left
+		}
+		p.isLeaf = false
+		p.site = nil
+		p.left, p.right = left, right
+		left.parent, right.parent = p, p
+		return pairs
+	}
+
+	// the arc above is split in two, with the new arc between
+	p0 := initiationParabola1(p.site)
+	p1 := initiationParabola1(site)
+	p2 := initiationParabola1(p.site)
+	p.isLeaf = false
+	p.site = nil
+	p.left = InitiationParabola2()
+	p.left.parent = p
+	p.left.left, p.left.right = p0, p1
+	p0.parent, p1.parent = p.left, p.left
+	p.right = p2
+	p2.parent = p
+
+	checkCircle(p0, site.y, queue)
+	checkCircle(p2, site.y, queue)
+	return pairs
+}
+
+/*
+	removeParabola closes the arc of the circle event e, which brings the arcs on either
+	side of it together, and pairs their sites.
+*/
+
+func removeParabola(e *VEvent, queue *eventQueue, pairs [][2]*VSite) [][2]*VSite {
+	p1 := e.arch
+	edgeLeft := GetLeftParent(p1)
+	edgeRight := GetRightParent(p1)
+	if edgeLeft == nil || edgeRight == nil {
+		return pairs
+	}
+	p0 := GetLeftChild(edgeLeft)
+	p2 := GetRightChild(edgeRight)
+	pairs = append(pairs, [2]*VSite{p0.site, p2.site})
+
+	// the other child of p1's parent takes the parent's place
+	parent := p1.parent
+	other := parent.left
+	if other == p1 {
+		other = parent.right
+	}
+	gparent := parent.parent
+	if gparent.left == parent {
+		gparent.left = other
+	} else {
+		gparent.right = other
+	}
+	other.parent = gparent
+	p1.parent = nil
+	p1.cEvent = nil
+
+	checkCircle(p0, e.y, queue)
+	checkCircle(p2, e.y, queue)
+	return pairs
+}
+
+/*
+	checkCircle decides whether the arc p will be closed by the arcs on either side of
+	it: their sites and p's must turn clockwise, so that the breakpoints move towards
+	each other, and the circle through them must reach below the sweep line at Y. The
+	circle event is kept in p.cEvent and queued, replacing any earlier one.
+*/
+
+func checkCircle(p *VParabola, Y float64, queue *eventQueue) {
+	queue.cancelCircle(p)
+	edgeLeft := GetLeftParent(p)
+	edgeRight := GetRightParent(p)
+	if edgeLeft == nil || edgeRight == nil {
+		return
+	}
+	a := GetLeftChild(edgeLeft).site
+	b := p.site
+	c := GetRightChild(edgeRight).site
+	if a == c {
+		return
+	}
+
+	// relative to a, for precision
+	bx, by := b.x-a.x, b.y-a.y
+	cx, cy := c.x-a.x, c.y-a.y
+	d := 2 * (bx*cy - by*cx)
+	if d >= 0 { // counter clockwise or in a line
+		return
+	}
+	b2, c2 := bx*bx+by*by, cx*cx+cy*cy
+	ux := (cy*b2 - by*c2) / d
+	uy := (bx*c2 - cx*b2) / d
+	r := math.Sqrt(ux*ux + uy*uy)
+
+	// the bottom of the circle, which rounding may put just above the sweep line
+	e := &VEvent{x: a.x + ux, y: math.Min(a.y+uy-r, Y), arch: p}
+	p.cEvent = e
+	heap.Push(queue, e)
+}
+
+// getCorrespondingPar returns the arc of the beach line above siteX when the sweep line is at Y
+func getCorrespondingPar(tree *VParabola, siteX, Y float64) *VParabola {
+	parabola := tree
+	for !parabola.isLeaf {
+		if GetXOfEdge(parabola, Y) > siteX {
+			parabola = parabola.left
+		} else {
+			parabola = parabola.right
+		}
+	}
+	return parabola
+}
+
+/*
+	GetXOfEdge returns the x of the breakpoint parabola when the sweep line is at Y:
+	where the arc on its left meets the arc on its right. The arc of a site on the sweep
+	line is a vertical ray below it, and two sites level with each other meet half way.
+*/
+
+func GetXOfEdge(parabola *VParabola, Y float64) float64 {
+	l := GetLeftChild(parabola).site
+	r := GetRightChild(parabola).site
+	if l.y == r.y {
+		return (l.x + r.x) / 2
+	}
+	if l.y == Y {
+		return l.x
+	}
+	if r.y == Y {
+		return r.x
+	}
+
+	// the arcs are y = ((x-sx)^2 + sy^2 - Y^2) / (2(sy-Y)); where they meet solves a*x^2 + b*x + c = 0
+	dl, dr := 2*(l.y-Y), 2*(r.y-Y)
+	a := 1/dl - 1/dr
+	b := -2 * (l.x/dl - r.x/dr)
+	c := l.x*l.x/dl - r.x*r.x/dr + (l.y-r.y)/2
+	disc := math.Sqrt(math.Max(b*b-4*a*c, 0))
+	x1 := (-b - disc) / (2 * a)
+	x2 := (-b + disc) / (2 * a)
+
+	// the arc nearer the sweep line is the narrower, and lies below the other between the two crossings
+	if l.y < r.y {
+		return max(x1, x2)
+	}
+	return min(x1, x2)
+}
+
+func max(a, b float64) float64 {
+	if a >= b {
+		return a
+	}
+
+	return b
+}
+
+func min(a, b float64) float64 {
+	if a <= b {
+		return a
+	}
+
+	return b
+}
+
+// GetLeftChild takes a breakpoint and returns the last arc on its left
+func GetLeftChild(parabola *VParabola) *VParabola {
+	if parabola == nil {
+		return nil
+	}
+
+	rp := parabola.left
+	for !rp.isLeaf {
+		rp = rp.right
+	}
+
+	return rp
+}
+
+// GetLeftParent takes an arc and returns the breakpoint on its left, or nil for the first arc
+func GetLeftParent(parabola *VParabola) *VParabola {
+	if parabola == nil {
+		return nil
+	}
+
+	rp := parabola.parent
+	tail := parabola
+
+	// climb while coming from the left
+	for rp != nil && rp.left == tail {
+		tail = rp
+		rp = rp.parent
+	}
+
+	return rp
+}
+
+// GetRightChild takes a breakpoint and returns the first arc on its right
+func GetRightChild(parabola *VParabola) *VParabola {
+	if parabola == nil {
+		return nil
+	}
+
+	rp := parabola.right
+	for !rp.isLeaf {
+		rp = rp.left
+	}
+
+	return rp
+}
+
+// GetRightParent takes an arc and returns the breakpoint on its right, or nil for the last arc
+func GetRightParent(parabola *VParabola) *VParabola {
+	if parabola == nil {
+		return nil
+	}
+
+	rp := parabola.parent
+	tail := parabola
+
+	// climb while coming from the right
+	for rp != nil && rp.right == tail {
+		tail = rp
+		rp = rp.parent
+	}
+
+	return rp
+}
+
+func initiationParabola1(site *VSite) *VParabola {
+	var parabola VParabola
+
+	parabola.site = site
+	parabola.isLeaf = true
+
+	return &parabola
+}
+
+func InitiationParabola2() *VParabola {
+	var parabola VParabola
+
+	parabola.isLeaf = false
+
+	return &parabola
+}
+
+/*
+	eventQueue is the event queue of the sweep, a heap of events with the next one first
+	that keeps every event's position in it, so that a circle event can be taken out
+	again when its arc is split or closed by another event first. It is used through
+	container/heap.
+*/
+
+type eventQueue []*VEvent
+
+func (q eventQueue) Len() int { return len(q) }
+
+// Less puts higher events first, and from left to right at the same height
+func (q eventQueue) Less(i, j int) bool {
+	if q[i].y != q[j].y {
+		return q[i].y > q[j].y
+	}
+	return q[i].x < q[j].x
+}
+
+func (q eventQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *eventQueue) Push(x interface{}) {
+	e := x.(*VEvent)
+	e.index = len(*q)
+	*q = append(*q, e)
+}
+
+func (q *eventQueue) Pop() interface{} {
+	old := *q
+	e := old[len(old)-1]
+	old[len(old)-1] = nil
+	e.index = -1
+	*q = old[:len(old)-1]
+	return e
+}
+
+// cancelCircle takes the circle event of the arc p out of the queue, if it has one
+func (q *eventQueue) cancelCircle(p *VParabola) {
+	if p.cEvent != nil {
+		if p.cEvent.index >= 0 {
+			heap.Remove(q, p.cEvent.index)
+		}
+		p.cEvent = nil
+	}
+}
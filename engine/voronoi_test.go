@@ -0,0 +1,343 @@
+package engine
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// areaEpsilon is how far, relative to the area of the board, the areas of the cells may sum away from it
+const areaEpsilon = 1e-6
+
+// voronoiCases are boards of 100 by 100 that have made the sweep go wrong, or could
+func voronoiCases() []struct {
+	name  string
+	cells []Cell
+} {
+	const w = 100.0
+	rng := rand.New(rand.NewSource(3))
+	points := func(xy ...float64) []Cell {
+		cells := make([]Cell, 0, len(xy)/2)
+		for i := 0; i+1 < len(xy); i += 2 {
+			cells = append(cells, NewCell(xy[i], xy[i+1]))
+		}
+		return cells
+	}
+	random := func(n int, x, y, spread float64) []Cell {
+		cells := make([]Cell, n)
+		for i := range cells {
+			cells[i] = NewCell(x+(rng.Float64()-0.5)*spread, y+(rng.Float64()-0.5)*spread)
+		}
+		return cells
+	}
+	lattice := func(k int, step, offset float64) []Cell {
+		var cells []Cell
+		for i := 0; i < k; i++ {
+			for j := 0; j < k; j++ {
+				cells = append(cells, NewCell(offset+float64(i)*step, offset+float64(j)*step))
+			}
+		}
+		return cells
+	}
+	circle := func(k int, x, y, r float64) []Cell {
+		var cells []Cell
+		for i := 0; i < k; i++ {
+			a := 2 * math.Pi * float64(i) / float64(k)
+			cells = append(cells, NewCell(x+r*math.Cos(a), y+r*math.Sin(a)))
+		}
+		return cells
+	}
+	line := func(k int, x0, y0, dx, dy float64) []Cell {
+		var cells []Cell
+		for i := 0; i < k; i++ {
+			cells = append(cells, NewCell(x0+float64(i)*dx, y0+float64(i)*dy))
+		}
+		return cells
+	}
+	repeat := func(cells []Cell, times int) []Cell {
+		var out []Cell
+		for t := 0; t < times; t++ {
+			out = append(out, cells...)
+		}
+		return out
+	}
+
+	return []struct {
+		name  string
+		cells []Cell
+	}{
+		{"no sites", nil},
+		{"one site", points(30, 70)},
+		{"two sites", points(20, 20, 80, 60)},
+		{"two sites level", points(20, 50, 80, 50)},
+		{"two sites one above the other", points(50, 20, 50, 80)},
+		{"random", random(500, w/2, w/2, w)},
+		{"random colony", random(300, w/2, w/2, 10)},
+		{"coincident", repeat(points(40, 40), 5)},
+		{"duplicates", append(repeat(random(50, w/2, w/2, w), 3), points(10, 10, 10, 10)...)},
+		{"duplicates in a line", repeat(line(10, 5, 5, 9, 0), 2)},
+		{"collinear horizontal", line(20, 2, 50, 5, 0)},
+		{"collinear vertical", line(20, 50, 2, 0, 5)},
+		{"collinear diagonal", line(20, 2, 2, 5, 5)},
+		{"collinear steep", line(20, 40, 0, 1, 5)},
+		{"square lattice", lattice(10, 10, 5)},
+		{"square lattice from the origin", lattice(11, 10, 0)},
+		{"square lattice of 2", lattice(2, 50, 25)},
+		{"co-circular", circle(24, w/2, w/2, 30)},
+		{"co-circular with centre", append(circle(16, w/2, w/2, 30), NewCell(w/2, w/2))},
+		{"concentric circles", append(circle(12, w/2, w/2, 40), circle(12, w/2, w/2, 20)...)},
+		{"corners", points(0, 0, w, 0, w, w, 0, w)},
+		{"corners and centre", points(0, 0, w, 0, w, w, 0, w, w/2, w/2)},
+		{"border", append(append(line(10, 0, 5, 0, 10), line(10, w, 5, 0, 10)...), append(line(10, 5, 0, 10, 0), line(10, 5, w, 10, 0)...)...)},
+		{"border and random", append(points(0, 0, 0, w, w, 0, w, w, 0, 50, 50, 0), random(100, w/2, w/2, w)...)},
+	}
+}
+
+func TestVoronoiAreasCoverBoard(t *testing.T) {
+	const w = 100.0
+	for _, tc := range voronoiCases() {
+		t.Run(tc.name, func(t *testing.T) {
+			sites, polygons := VoronoiPolygons(tc.cells, w)
+			if len(polygons) != len(sites) {
+				t.Fatalf("%d polygons for %d sites", len(polygons), len(sites))
+			}
+			if len(sites) == 0 {
+				return
+			}
+			total := 0.0
+			for i := range polygons {
+				total += polygons[i].area()
+			}
+			if math.Abs(total-w*w) > areaEpsilon*w*w {
+				t.Errorf("areas of %d sites sum to %g, not %g", len(sites), total, w*w)
+			}
+		})
+	}
+}
+
+/*
+	TestVoronoiSweep checks the cells cut by the neighbours Fortune's algorithm finds
+	alone, without the search VoronoiPolygons falls back on, against the cells cut by
+	the nearest sites.
+*/
+
+func TestVoronoiSweep(t *testing.T) {
+	const w = 100.0
+	for _, tc := range voronoiCases() {
+		t.Run(tc.name, func(t *testing.T) {
+			sites := siteList(tc.cells)
+			if len(sites) == 0 {
+				return
+			}
+			swept := sweptPolygons(sites, w)
+			nearest := make([]cellPolygon, len(sites))
+			nearestPolygons(sites, nearest, w)
+			total := 0.0
+			for i := range swept {
+				total += swept[i].area()
+				if math.Abs(swept[i].area()-nearest[i].area()) > areaEpsilon*w*w {
+					t.Errorf("site %d at (%g, %g): swept area %g, nearest area %g", i, sites[i].x, sites[i].y, swept[i].area(), nearest[i].area())
+				}
+			}
+			if math.Abs(total-w*w) > areaEpsilon*w*w {
+				t.Errorf("swept areas of %d sites sum to %g, not %g", len(sites), total, w*w)
+			}
+		})
+	}
+}
+
+func TestVoronoiDensity(t *testing.T) {
+	const w = 100.0
+	for _, tc := range voronoiCases() {
+		t.Run(tc.name, func(t *testing.T) {
+			board := NewGameBoard(tc.cells, w, rand.New(NewSource(1)))
+			board = Voronoi(&board)
+			total := 0.0
+			for i, c := range board.Cells() {
+				if math.IsNaN(c.Density()) || math.IsInf(c.Density(), 0) || c.Density() <= 0 {
+					t.Fatalf("cell %d at (%g, %g) has density %g", i, c.X(), c.Y(), c.Density())
+				}
+				total += GetArea(c) / float64(sameAs(board.Cells(), c))
+			}
+			if len(tc.cells) > 0 && math.Abs(total-w*w) > areaEpsilon*w*w {
+				t.Errorf("areas of %d cells sum to %g, not %g", len(tc.cells), total, w*w)
+			}
+		})
+	}
+}
+
+/*
+	TestVoronoiDensityFormula checks the density of two cells splitting the board in
+	halves, 1/(area+0.1), and that a cell alone on the board keeps the density of
+	width*width it has always had.
+*/
+
+func TestVoronoiDensityFormula(t *testing.T) {
+	const w = 100.0
+	board := NewGameBoard([]Cell{NewCell(25, 50), NewCell(75, 50)}, w, rand.New(NewSource(1)))
+	for _, c := range Voronoi(&board).Cells() {
+		if want := 1 / (w*w/2 + 0.1); !near(c.Density(), want) {
+			t.Errorf("cell at (%g, %g) of two has density %g, want %g", c.X(), c.Y(), c.Density(), want)
+		}
+	}
+	board = NewGameBoard([]Cell{NewCell(30, 40)}, w, rand.New(NewSource(1)))
+	if c := Voronoi(&board).Cells()[0]; c.Density() != w*w {
+		t.Errorf("a lone cell has density %g, want %g", c.Density(), w*w)
+	}
+}
+
+// sameAs returns the number of cells at the place of c, c included
+func sameAs(cells []Cell, c Cell) int {
+	n := 0
+	for _, other := range cells {
+		if other.x == c.x && other.y == c.y {
+			n++
+		}
+	}
+	return n
+}
+
+/*
+	TestFalseCircleEvent sweeps A, B and C, whose arcs queue a circle event closing B's
+	arc at the bottom of the circle through them, then D, which lies inside that circle
+	but is swept before its bottom and splits B's arc. The circle event must be taken out
+	of the queue, or A and C would be paired although D and B separate them.
+*/
+
+func TestFalseCircleEvent(t *testing.T) {
+	a, b, c, d := NewCell(45, 60), NewCell(50, 62), NewCell(55, 60), NewCell(50, 55)
+	sites := siteList([]Cell{a, b, c, d})
+
+	var tree *VParabola
+	queue := make(eventQueue, len(sites))
+	for i, site := range sites {
+		queue[i] = &VEvent{x: site.x, y: site.y, site: site, index: i}
+	}
+	heap.Init(&queue)
+	var pairs [][2]*VSite
+	var pending []*VEvent
+	for queue.Len() != 0 {
+		e := heap.Pop(&queue).(*VEvent)
+		if e.site == sites[3] {
+			for _, queued := range queue {
+				if queued.site == nil {
+					pending = append(pending, queued)
+				}
+			}
+		}
+		if e.site != nil {
+			pairs = insertParabola(&tree, e, &queue, pairs)
+		} else {
+			pairs = removeParabola(e, &queue, pairs)
+		}
+		if e.site == sites[3] {
+			if len(pending) == 0 {
+				t.Fatal("no circle event was queued before D")
+			}
+			for _, p := range pending {
+				if p.index != -1 {
+					t.Errorf("circle event at (%g, %g) is still queued after D split its arc", p.x, p.y)
+				}
+			}
+		}
+	}
+
+	checkDelaunay(t, sites, pairs)
+}
+
+func TestNeighboursAreDelaunay(t *testing.T) {
+	rng := rand.New(rand.NewSource(5))
+	for _, n := range []int{3, 4, 5, 10, 30, 60} {
+		for trial := 0; trial < 5; trial++ {
+			cells := make([]Cell, n)
+			for i := range cells {
+				cells[i] = NewCell(rng.Float64()*100, rng.Float64()*100)
+			}
+			sites := siteList(cells)
+			checkDelaunay(t, sites, GetNeighbours(sites))
+		}
+	}
+}
+
+/*
+	checkDelaunay compares pairs with the edges of the Delaunay triangulation of sites,
+	found by trying every triangle for a circumcircle with no other site inside. The
+	sites must not have four on a common circle, where the triangulation is not unique.
+*/
+
+func checkDelaunay(t *testing.T, sites []*VSite, pairs [][2]*VSite) {
+	t.Helper()
+	got := make(map[[2]int]bool)
+	for _, pair := range pairs {
+		s, u := pair[0].index, pair[1].index
+		if s > u {
+			s, u = u, s
+		}
+		got[[2]int{s, u}] = true
+	}
+
+	want := make(map[[2]int]bool)
+	for i := range sites {
+		for j := i + 1; j < len(sites); j++ {
+			for k := j + 1; k < len(sites); k++ {
+				if emptyCircle(sites, i, j, k) {
+					want[[2]int{i, j}], want[[2]int{j, k}], want[[2]int{i, k}] = true, true, true
+				}
+			}
+		}
+	}
+
+	for pair := range want {
+		if !got[pair] {
+			t.Errorf("%d sites: Delaunay edge %v not found by the sweep", len(sites), pair)
+		}
+	}
+	for pair := range got {
+		if !want[pair] {
+			t.Errorf("%d sites: sweep paired %v, which is not a Delaunay edge", len(sites), pair)
+		}
+	}
+}
+
+// emptyCircle reports whether no site other than i, j and k is inside the circle through them
+func emptyCircle(sites []*VSite, i, j, k int) bool {
+	a, b, c := sites[i], sites[j], sites[k]
+	bx, by := b.x-a.x, b.y-a.y
+	cx, cy := c.x-a.x, c.y-a.y
+	d := 2 * (bx*cy - by*cx)
+	if d == 0 {
+		return false
+	}
+	b2, c2 := bx*bx+by*by, cx*cx+cy*cy
+	ux, uy := (cy*b2-by*c2)/d, (bx*c2-cx*b2)/d
+	r2 := ux*ux + uy*uy
+	for m, s := range sites {
+		if m == i || m == j || m == k {
+			continue
+		}
+		dx, dy := s.x-a.x-ux, s.y-a.y-uy
+		if dx*dx+dy*dy < r2 {
+			return false
+		}
+	}
+	return true
+}
+
+func BenchmarkVoronoi(b *testing.B) {
+	const width = 500.0
+	rng := rand.New(rand.NewSource(1))
+	for _, n := range []int{1000, 10000, 50000} {
+		cells := make([]Cell, n)
+		for i := range cells {
+			cells[i] = NewCell(rng.Float64()*width, rng.Float64()*width)
+		}
+		board := NewGameBoard(cells, width, rand.New(NewSource(1)))
+		b.Run(fmt.Sprintf("sites=%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				Voronoi(&board)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"strings"
+)
+
+// FrameFormats are the formats FrameOptions.Format can write the frames of a run in
+var FrameFormats = []string{"gif", "png", "apng", "avi"}
+
+// GIFPalettes are the ways FrameOptions.Palette can give the frames of a gif their colours
+var GIFPalettes = []string{"frame", "global"}
+
+/*
+	FrameWriter takes the frames of a run one at a time, as they are drawn, and writes
+	them out in some format. Close finishes the output after the last frame; it is an
+	error to close one that was given no frames.
+*/
+
+type FrameWriter interface {
+	AddFrame(img image.Image) error
+	Close() error
+}
+
+/*
+	FrameOptions are how the frames of a run are written. Format is one of FrameFormats:
+
+		gif   NAME.gif, at most 256 colours per frame
+		png   NAME_00000.png, NAME_00001.png, ..., one full colour image per frame
+		apng  NAME.png, an animated PNG in full colour
+		avi   NAME.avi, a Motion JPEG video that video editors and slides take in
+
+	Stride is the number of generations between frames, or 0 for the model's own: every
+	third generation for one cluster and every second for two cluster. Delay is how long
+	each frame is shown for, in hundredths of a second, and LoopCount the number of times
+	a gif or animated PNG repeats, 0 for ever. With the frame palette each frame of a
+	gif is reduced to its own 256 colours by median cut; the global palette is one fixed
+	palette of 256 colours, the Plan 9 palette, written once for the whole gif, so that
+	colours do not flicker from frame to frame. Comment, if not empty, is written into
+	the file: as a comment extension of a gif, a tEXt chunk of each PNG and an INFO
+	comment of an AVI.
+*/
+
+type FrameOptions struct {
+	Format    string
+	Stride    int
+	Delay     int
+	LoopCount int
+	Palette   string
+	Comment   string
+}
+
+// DefaultFrameOptions returns the options gifs were always made with
+func DefaultFrameOptions() FrameOptions {
+	return FrameOptions{Format: "gif", Delay: 1, LoopCount: 10, Palette: "frame"}
+}
+
+// validate returns an error naming the first option out of range, or nil
+func (o FrameOptions) validate() error {
+	switch {
+	case !contains(FrameFormats, o.Format):
+		return fmt.Errorf("--format must be one of %s, not %q", strings.Join(FrameFormats, ", "), o.Format)
+	case o.Stride < 0:
+		return fmt.Errorf("--stride must be at least 0, not %d", o.Stride)
+	case o.Delay < 0 || o.Delay > 0xffff:
+		return fmt.Errorf("--delay must be from 0 to 65535, not %d", o.Delay)
+	case o.LoopCount < 0 || o.LoopCount > 0xffff:
+		return fmt.Errorf("--loopCount must be from 0 to 65535, not %d", o.LoopCount)
+	case !contains(GIFPalettes, o.Palette):
+		return fmt.Errorf("--palette must be one of %s, not %q", strings.Join(GIFPalettes, ", "), o.Palette)
+	}
+	return nil
+}
+
+// stride returns the generations between frames, with model for the model's own stride
+func (o FrameOptions) stride(model int) int {
+	if o.Stride > 0 {
+		return o.Stride
+	}
+	return model
+}
+
+// NewFrameWriter creates the output of the frames of a run saved as filename, in the format of opts
+func NewFrameWriter(filename string, opts FrameOptions) (FrameWriter, error) {
+	switch opts.Format {
+	case "", "gif":
+		return NewGIFWriter(filename, opts)
+	case "png":
+		return &pngSequence{filename: filename, comment: opts.Comment}, nil
+	case "apng":
+		return NewAPNGWriter(filename, opts)
+	case "avi":
+		return NewAVIWriter(filename, opts)
+	}
+	return nil, fmt.Errorf("unknown frame format %q", opts.Format)
+}
+
+// pngSequence writes each frame to a PNG file of its own, numbered from 0
+type pngSequence struct {
+	filename string
+	comment  string
+	frames   int
+}
+
+func (p *pngSequence) AddFrame(img image.Image) error {
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, img); err != nil {
+		return err
+	}
+	frame := encoded.Bytes()
+	if p.comment != "" {
+		// the comment goes straight after IHDR, which every PNG starts with
+		var commented bytes.Buffer
+		commented.Write(frame[:acTLOffset])
+		writePNGChunk(&commented, "tEXt", pngText("Comment", p.comment))
+		commented.Write(frame[acTLOffset:])
+		frame = commented.Bytes()
+	}
+	err := os.WriteFile(fmt.Sprintf("%s_%05d.png", p.filename, p.frames), frame, 0644)
+	p.frames++
+	return err
+}
+
+func (p *pngSequence) Close() error {
+	if p.frames == 0 {
+		return fmt.Errorf("png: no frames to write")
+	}
+	return nil
+}
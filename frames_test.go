@@ -0,0 +1,301 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// noiseFrames returns n frames of random pixels, which do not compress, so that each PNG of them has several IDAT chunks
+func noiseFrames(n, width, height int) []*image.RGBA {
+	rng := rand.New(rand.NewSource(1))
+	frames := make([]*image.RGBA, n)
+	for i := range frames {
+		frames[i] = image.NewRGBA(image.Rect(0, 0, width, height))
+		rng.Read(frames[i].Pix)
+		for k := 3; k < len(frames[i].Pix); k += 4 {
+			frames[i].Pix[k] = 255
+		}
+	}
+	return frames
+}
+
+// writeFrames writes frames with a FrameWriter made by opts, and returns the bytes of the file name it wrote
+func writeFrames(t *testing.T, opts FrameOptions, name string, frames []*image.RGBA) []byte {
+	t.Helper()
+	dir := t.TempDir()
+	out, err := NewFrameWriter(filepath.Join(dir, "run"), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, frame := range frames {
+		if err := out.AddFrame(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := out.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// samePixels reports whether img has the pixels of want
+func samePixels(img image.Image, want *image.RGBA) bool {
+	if img.Bounds() != want.Bounds() {
+		return false
+	}
+	for y := want.Rect.Min.Y; y < want.Rect.Max.Y; y++ {
+		for x := want.Rect.Min.X; x < want.Rect.Max.X; x++ {
+			r, g, b, a := img.At(x, y).RGBA()
+			if color.RGBA64(want.RGBA64At(x, y)) != (color.RGBA64{uint16(r), uint16(g), uint16(b), uint16(a)}) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// readPNGChunks splits a PNG file into its chunks, checking the CRC of each
+func readPNGChunks(t *testing.T, data []byte) []pngChunk {
+	t.Helper()
+	if !bytes.HasPrefix(data, []byte(pngSignature)) {
+		t.Fatal("no PNG signature")
+	}
+	var chunks []pngChunk
+	for rest := data[len(pngSignature):]; len(rest) > 0; {
+		n := int(binary.BigEndian.Uint32(rest))
+		if len(rest) < 12+n {
+			t.Fatalf("chunk %d is cut short", len(chunks))
+		}
+		if crc32.ChecksumIEEE(rest[4:8+n]) != binary.BigEndian.Uint32(rest[8+n:]) {
+			t.Errorf("chunk %d, %s, has a bad CRC", len(chunks), rest[4:8])
+		}
+		chunks = append(chunks, pngChunk{kind: string(rest[4:8]), data: rest[8 : 8+n]})
+		rest = rest[12+n:]
+	}
+	return chunks
+}
+
+/*
+	TestAPNG writes three frames of 200x150 random pixels and walks the chunks: IHDR,
+	acTL with the number of frames and plays, the comment, then for each frame an fcTL
+	and its image data, IDAT for the first and fdAT for the others, with the fcTL and
+	fdAT chunks numbered from 0 without a gap, and IEND. image/png must decode the first
+	frame from the file, and each other frame once its fdAT chunks are made IDAT again.
+*/
+
+func TestAPNG(t *testing.T) {
+	frames := noiseFrames(3, 200, 150)
+	opts := FrameOptions{Format: "apng", Delay: 7, LoopCount: 2, Comment: "seed: 5"}
+	data := writeFrames(t, opts, "run.png", frames)
+
+	first, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !samePixels(first, frames[0]) {
+		t.Error("image/png decodes the animation to other pixels than the first frame")
+	}
+
+	chunks := readPNGChunks(t, data)
+	if len(chunks) < 4 || chunks[0].kind != "IHDR" || chunks[1].kind != "acTL" || chunks[2].kind != "tEXt" || chunks[len(chunks)-1].kind != "IEND" {
+		t.Fatalf("chunks do not start IHDR, acTL, tEXt and end with IEND")
+	}
+	if got := binary.BigEndian.Uint32(chunks[1].data); got != 3 {
+		t.Errorf("acTL has %d frames, want 3", got)
+	}
+	if got := binary.BigEndian.Uint32(chunks[1].data[4:]); got != 3 {
+		t.Errorf("acTL has %d plays, want 3 for a loop count of 2", got)
+	}
+	if string(chunks[2].data) != "Comment\x00seed: 5" {
+		t.Errorf("tEXt holds %q", chunks[2].data)
+	}
+
+	var sequence uint32
+	frame := -1
+	var idat [][]byte // image data of each frame
+	for _, c := range chunks[3 : len(chunks)-1] {
+		switch c.kind {
+		case "fcTL":
+			frame++
+			idat = append(idat, nil)
+			if len(c.data) != 26 {
+				t.Fatalf("fcTL of frame %d has %d bytes", frame, len(c.data))
+			}
+			w, h := binary.BigEndian.Uint32(c.data[4:]), binary.BigEndian.Uint32(c.data[8:])
+			num, den := binary.BigEndian.Uint16(c.data[20:]), binary.BigEndian.Uint16(c.data[22:])
+			if w != 200 || h != 150 || num != 7 || den != 100 {
+				t.Errorf("fcTL of frame %d is %dx%d for %d/%d s, want 200x150 for 7/100 s", frame, w, h, num, den)
+			}
+		case "IDAT":
+			if frame != 0 {
+				t.Fatalf("IDAT in frame %d", frame)
+			}
+			idat[0] = append(idat[0], c.data...)
+			continue
+		case "fdAT":
+			if frame < 1 {
+				t.Fatalf("fdAT in frame %d", frame)
+			}
+			idat[frame] = append(idat[frame], c.data[4:]...)
+		default:
+			t.Fatalf("unexpected %s chunk in frame %d", c.kind, frame)
+		}
+		if got := binary.BigEndian.Uint32(c.data); got != sequence {
+			t.Fatalf("%s of frame %d has sequence number %d, want %d", c.kind, frame, got, sequence)
+		}
+		sequence++
+	}
+	if len(idat) != 3 || sequence <= 3+2 {
+		t.Fatalf("%d frames with %d fcTL and fdAT chunks, want 3 frames of several chunks each", len(idat), sequence)
+	}
+
+	for k := 1; k < len(idat); k++ {
+		var alone bytes.Buffer
+		alone.WriteString(pngSignature)
+		writePNGChunk(&alone, "IHDR", chunks[0].data)
+		writePNGChunk(&alone, "IDAT", idat[k])
+		writePNGChunk(&alone, "IEND", nil)
+		img, err := png.Decode(&alone)
+		if err != nil {
+			t.Fatalf("frame %d: %v", k, err)
+		}
+		if !samePixels(img, frames[k]) {
+			t.Errorf("frame %d decodes to other pixels than were written", k)
+		}
+	}
+}
+
+func TestAPNGErrors(t *testing.T) {
+	a, err := NewAPNGWriter(filepath.Join(t.TempDir(), "run"), FrameOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.AddFrame(noiseFrames(1, 20, 10)[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.AddFrame(noiseFrames(1, 10, 20)[0]); err == nil {
+		t.Error("no error adding a frame of another size")
+	}
+	a.Close()
+
+	a, err = NewAPNGWriter(filepath.Join(t.TempDir(), "run"), FrameOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Close(); err == nil {
+		t.Error("no error closing an animation of no frames")
+	}
+}
+
+// flatFrames returns frames of width by height, each of one colour of colours
+func flatFrames(width, height int, colours ...color.RGBA) []*image.RGBA {
+	frames := make([]*image.RGBA, len(colours))
+	for i, c := range colours {
+		frames[i] = image.NewRGBA(image.Rect(0, 0, width, height))
+		for k := 0; k < len(frames[i].Pix); k += 4 {
+			copy(frames[i].Pix[k:], []byte{c.R, c.G, c.B, c.A})
+		}
+	}
+	return frames
+}
+
+// near8 reports whether the 16 bit channel v is within a few steps of the 8 bit channel want, as JPEG keeps it
+func near8(v uint32, want uint8) bool {
+	d := int(v>>8) - int(want)
+	return d >= -6 && d <= 6
+}
+
+/*
+	TestAVI writes three frames, with and without a comment, and walks the RIFF file:
+	its size, the hdrl list with the counts Close fills in, the INFO list with the
+	comment, the movi list of the frames and idx1, whose entries must point at the
+	frames from the 'movi' fourcc and give their sizes. Each frame must decode with
+	image/jpeg to its colour.
+*/
+
+func TestAVI(t *testing.T) {
+	colours := []color.RGBA{{200, 30, 30, 255}, {30, 200, 30, 255}, {30, 30, 200, 255}}
+	for _, comment := range []string{"", "seed: 5", "seed: 42"} {
+		data := writeFrames(t, FrameOptions{Format: "avi", Delay: 4, Comment: comment}, "run.avi", flatFrames(64, 48, colours...))
+		le := func(at int) int { return int(binary.LittleEndian.Uint32(data[at:])) }
+
+		if string(data[:4]) != "RIFF" || string(data[8:12]) != "AVI " || le(4) != len(data)-8 {
+			t.Fatalf("comment %q: RIFF header %q of size %d for a file of %d bytes", comment, data[:12], le(4), len(data))
+		}
+		if le(aviTotalFrames) != 3 || le(aviLength) != 3 || le(32) != 40000 || le(aviAVIHBuffer) != le(aviSTRHBuffer) {
+			t.Errorf("comment %q: avih frames %d, strh length %d, %d µs per frame; want 3, 3, 40000", comment, le(aviTotalFrames), le(aviLength), le(32))
+		}
+
+		lists := make(map[string]int) // where the data of each top level chunk starts, by fourcc or list type
+		for at := 12; at < len(data); {
+			kind, size := string(data[at:at+4]), le(at+4)
+			if kind == "LIST" {
+				kind = string(data[at+8 : at+12])
+			}
+			lists[kind] = at + 8
+			at += 8 + size + size%2
+			if at > len(data) {
+				t.Fatalf("comment %q: %s runs past the end of the file", comment, kind)
+			}
+		}
+		if _, ok := lists["hdrl"]; !ok {
+			t.Fatalf("comment %q: no hdrl list", comment)
+		}
+		if info, ok := lists["INFO"]; ok != (comment != "") {
+			t.Errorf("comment %q: INFO list %v", comment, ok)
+		} else if ok && (string(data[info+4:info+8]) != "ICMT" || string(data[info+12:info+12+len(comment)+1]) != comment+"\x00") {
+			t.Errorf("comment %q: INFO list holds %q", comment, data[info+4:info+12+len(comment)+1])
+		}
+		movi, idx1 := lists["movi"], lists["idx1"]
+		if movi == 0 || idx1 == 0 || le(idx1-4) != 16*3 {
+			t.Fatalf("comment %q: movi at %d, idx1 at %d of %d bytes", comment, movi, idx1, le(idx1-4))
+		}
+
+		largest := 0
+		for k := 0; k < 3; k++ {
+			entry := idx1 + 16*k
+			offset, size := le(entry+8), le(entry+12)
+			chunk := movi + offset // from the 'movi' fourcc
+			if string(data[entry:entry+4]) != "00dc" || string(data[chunk:chunk+4]) != "00dc" || le(chunk+4) != size {
+				t.Fatalf("comment %q: idx1 entry %d points at %q of size %d, with size %d", comment, k, data[chunk:chunk+4], le(chunk+4), size)
+			}
+			if size > largest {
+				largest = size
+			}
+			img, err := jpeg.Decode(bytes.NewReader(data[chunk+8 : chunk+8+size]))
+			if err != nil {
+				t.Fatalf("comment %q: frame %d: %v", comment, k, err)
+			}
+			r, g, b, _ := img.At(32, 24).RGBA()
+			if img.Bounds().Dx() != 64 || img.Bounds().Dy() != 48 || !near8(r, colours[k].R) || !near8(g, colours[k].G) || !near8(b, colours[k].B) {
+				t.Errorf("comment %q: frame %d is %v, coloured %d %d %d; want 64x48 of %v", comment, k, img.Bounds(), r>>8, g>>8, b>>8, colours[k])
+			}
+		}
+		if le(aviAVIHBuffer) != largest+8 {
+			t.Errorf("comment %q: suggested buffer %d, want %d", comment, le(aviAVIHBuffer), largest+8)
+		}
+	}
+}
+
+func TestAVINoFrames(t *testing.T) {
+	v, err := NewAVIWriter(filepath.Join(t.TempDir(), "run"), FrameOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Close(); err == nil {
+		t.Error("no error closing a video of no frames")
+	}
+}
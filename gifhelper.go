@@ -1,35 +1,103 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"gogif"
 	"image"
+	"image/color"
+	"image/color/palette"
 	"image/gif"
 	"os"
-	"gogif"
-	"fmt"
 )
 
-func Process(imglist []image.Image, filename string) {
+/*
+	GIFWriter writes a gif one frame at a time, so that a long simulation only holds the
+	frame being drawn instead of every frame until the end. Each frame is encoded as a
+	gif of its own, and its image block is copied after the header, loop extension and
+	comment extension written with the first frame.
+*/
 
-	// get ready to write images to files
-	w, err := os.Create(filename + ".gif")
+type GIFWriter struct {
+	file      *os.File
+	w         *bufio.Writer
+	delay     int
+	loopCount int
+	comment   string
+	global    color.Palette // the global colour table, nil for a palette per frame
+	frames    int
+}
 
+// NewGIFWriter creates filename.gif, made as opts says
+func NewGIFWriter(filename string, opts FrameOptions) (*GIFWriter, error) {
+	file, err := os.Create(filename + ".gif")
 	if err != nil {
-		fmt.Println("Sorry: couldn't create the file!")
-		os.Exit(1)
+		return nil, err
+	}
+	g := &GIFWriter{file: file, w: bufio.NewWriter(file), delay: opts.Delay, loopCount: opts.LoopCount, comment: opts.Comment}
+	if opts.Palette == "global" {
+		g.global = palette.Plan9
 	}
+	return g, nil
+}
 
-	defer w.Close()
-	var g gif.GIF
-	g.Delay = make([]int, len(imglist))
-	g.Image = make([]*image.Paletted, len(imglist))
-	g.LoopCount = 10
+// AddFrame reduces img to 256 colours and appends it to the gif
+func (g *GIFWriter) AddFrame(img image.Image) error {
+	var one bytes.Buffer
+	frame := &gif.GIF{Delay: []int{g.delay}}
+	if g.global != nil {
+		// a frame in the global palette is written without a colour table of its own
+		frame.Image = []*image.Paletted{toPalette(img, g.global)}
+		frame.Config = image.Config{ColorModel: g.global, Width: img.Bounds().Dx(), Height: img.Bounds().Dy()}
+	} else {
+		frame.Image = []*image.Paletted{ImageToPaletted(img)}
+	}
+	if err := gif.EncodeAll(&one, frame); err != nil {
+		return err
+	}
+	encoded := one.Bytes()
 
-	for i:=range(imglist) {
-		g.Image[i] = ImageToPaletted(imglist[i])
-		g.Delay[i] = 1
+	// 6 bytes of signature and 7 of screen descriptor, then the global colour table if there is one
+	header := 13 + 3*len(g.global)
+	if g.frames == 0 {
+		g.w.Write(encoded[:header])
+		g.w.Write([]byte{0x21, 0xff, 0x0b})
+		g.w.WriteString("NETSCAPE2.0")
+		g.w.Write([]byte{0x03, 0x01, byte(g.loopCount), byte(g.loopCount >> 8), 0x00})
+		if g.comment != "" {
+			g.w.Write([]byte{0x21, 0xfe})
+			for rest := g.comment; len(rest) > 0; {
+				// the comment is split into sub-blocks of at most 255 bytes
+				n := len(rest)
+				if n > 255 {
+					n = 255
+				}
+				g.w.WriteByte(byte(n))
+				g.w.WriteString(rest[:n])
+				rest = rest[n:]
+			}
+			g.w.WriteByte(0x00)
+		}
 	}
+	// everything else but the trailer is the frame
+	_, err := g.w.Write(encoded[header : len(encoded)-1])
+	g.frames++
+	return err
+}
 
-	gif.EncodeAll(w, &g)
+// Close ends the gif and closes its file
+func (g *GIFWriter) Close() error {
+	if g.frames == 0 {
+		g.file.Close()
+		return fmt.Errorf("gif: no frames to write")
+	}
+	g.w.WriteByte(0x3b)
+	if err := g.w.Flush(); err != nil {
+		g.file.Close()
+		return err
+	}
+	return g.file.Close()
 }
 
 // Converts an image to an image.Paletted with 256 colors.
@@ -43,3 +111,23 @@ func ImageToPaletted(img image.Image) *image.Paletted {
 	}
 	return pm
 }
+
+// toPalette converts img to the colours of p, each pixel to the nearest of them
+func toPalette(img image.Image, p color.Palette) *image.Paletted {
+	b := img.Bounds()
+	pm := image.NewPaletted(b, p)
+	// frames are drawn with few colours, so each is only looked up once
+	index := make(map[color.Color]uint8)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := img.At(x, y)
+			i, ok := index[c]
+			if !ok {
+				i = uint8(p.Index(c))
+				index[c] = i
+			}
+			pm.SetColorIndex(x, y, i)
+		}
+	}
+	return pm
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"image/color"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/*
+	glyphs are the letters of the small bitmap font the legend is written in, 3 pixels
+	wide and 5 high, so that no font files are needed. Text is written in capitals.
+*/
+
+var glyphs = map[rune][5]string{
+	'A': {".#.", "#.#", "###", "#.#", "#.#"}, 'B': {"##.", "#.#", "##.", "#.#", "##."},
+	'C': {".##", "#..", "#..", "#..", ".##"}, 'D': {"##.", "#.#", "#.#", "#.#", "##."},
+	'E': {"###", "#..", "##.", "#..", "###"}, 'F': {"###", "#..", "##.", "#..", "#.."},
+	'G': {".##", "#..", "#.#", "#.#", ".##"}, 'H': {"#.#", "#.#", "###", "#.#", "#.#"},
+	'I': {"###", ".#.", ".#.", ".#.", "###"}, 'J': {"..#", "..#", "..#", "#.#", ".#."},
+	'K': {"#.#", "#.#", "##.", "#.#", "#.#"}, 'L': {"#..", "#..", "#..", "#..", "###"},
+	'M': {"#.#", "###", "###", "#.#", "#.#"}, 'N': {"##.", "#.#", "#.#", "#.#", "#.#"},
+	'O': {".#.", "#.#", "#.#", "#.#", ".#."}, 'P': {"##.", "#.#", "##.", "#..", "#.."},
+	'Q': {".#.", "#.#", "#.#", "##.", ".##"}, 'R': {"##.", "#.#", "##.", "#.#", "#.#"},
+	'S': {".##", "#..", ".#.", "..#", "##."}, 'T': {"###", ".#.", ".#.", ".#.", ".#."},
+	'U': {"#.#", "#.#", "#.#", "#.#", "###"}, 'V': {"#.#", "#.#", "#.#", "#.#", ".#."},
+	'W': {"#.#", "#.#", "###", "###", "#.#"}, 'X': {"#.#", "#.#", ".#.", "#.#", "#.#"},
+	'Y': {"#.#", "#.#", ".#.", ".#.", ".#."}, 'Z': {"###", "..#", ".#.", "#..", "###"},
+	'0': {"###", "#.#", "#.#", "#.#", "###"}, '1': {".#.", "##.", ".#.", ".#.", "###"},
+	'2': {"##.", "..#", ".#.", "#..", "###"}, '3': {"##.", "..#", ".#.", "..#", "##."},
+	'4': {"#.#", "#.#", "###", "..#", "..#"}, '5': {"###", "#..", "##.", "..#", "##."},
+	'6': {".##", "#..", "###", "#.#", "###"}, '7': {"###", "..#", ".#.", ".#.", ".#."},
+	'8': {"###", "#.#", "###", "#.#", "###"}, '9': {"###", "#.#", "###", "..#", "##."},
+	'.': {"...", "...", "...", "...", ".#."}, '-': {"...", "...", "###", "...", "..."},
+	'+': {"...", ".#.", "###", ".#.", "..."},
+}
+
+const (
+	fontScale = 2               // canvas pixels per pixel of a glyph
+	charWidth = 4 * fontScale   // a glyph and the space after it
+	lineSize  = 6*fontScale + 2 // a line of text and the space under it
+	swatch    = 5 * fontScale   // side of the colour square of a category
+	barWidth  = 120             // length of the colour bar
+	barHeight = 4 * fontScale   // thickness of the colour bar
+	margin    = 6               // space around the legend, inside and out
+	maxKeys   = 8               // categories listed before the rest are counted
+)
+
+// textWidth returns the width of s written with drawText
+func textWidth(s string) int {
+	return len(s)*charWidth - fontScale
+}
+
+// drawText writes s in capitals with its top left corner at (x, y)
+func drawText(c *Canvas, x, y int, s string, col color.Color) {
+	c.SetFillColor(col)
+	for _, r := range strings.ToUpper(s) {
+		for row, line := range glyphs[r] {
+			for column, dot := range line {
+				if dot == '#' {
+					px, py := x+column*fontScale, y+row*fontScale
+					c.ClearRect(px, py, px+fontScale, py+fontScale)
+				}
+			}
+		}
+		x += charWidth
+	}
+}
+
+// label writes v with three significant digits
+func label(v float64) string {
+	return strconv.FormatFloat(v, 'g', 3, 64)
+}
+
+/*
+	drawLegend draws the key of scale in the bottom left corner of the canvas, on a
+	black box: the name of the attribute over a colour bar from its lowest value to its
+	highest for a continuous map, or a colour square for each of values for a
+	categorical one.
+*/
+
+func drawLegend(c *Canvas, scale colorScale, values []float64) {
+	white := MakeColor(255, 255, 255)
+	var keys []float64
+	width := textWidth(scale.name)
+	height := lineSize
+	if scale.categorical {
+		keys = distinct(values)
+		for i, v := range keys {
+			if i == maxKeys {
+				break
+			}
+			width = maxInt(width, swatch+fontScale+textWidth(label(v)))
+		}
+		rows := len(keys)
+		if rows > maxKeys {
+			rows = maxKeys + 1
+		}
+		height += rows * lineSize
+	} else {
+		width = maxInt(width, barWidth)
+		height += barHeight + 2 + lineSize
+	}
+
+	x, y := margin+margin, c.Height()-margin-margin-height
+	c.SetFillColor(MakeColor(0, 0, 0))
+	c.ClearRect(x-margin, y-margin, x+width+margin, y+height+margin)
+	drawText(c, x, y, scale.name, white)
+	y += lineSize
+
+	if !scale.categorical {
+		for k := 0; k < barWidth; k++ {
+			c.SetFillColor(scale.at(float64(k) / float64(barWidth-1)))
+			c.ClearRect(x+k, y, x+k+1, y+barHeight)
+		}
+		y += barHeight + 2
+		drawText(c, x, y, label(scale.lo), white)
+		hi := label(scale.hi)
+		drawText(c, x+barWidth-textWidth(hi), y, hi, white)
+		return
+	}
+	for i, v := range keys {
+		if i == maxKeys {
+			drawText(c, x, y, "+"+strconv.Itoa(len(keys)-maxKeys), white)
+			break
+		}
+		c.SetFillColor(scale.color(v))
+		c.ClearRect(x, y, x+swatch, y+swatch)
+		drawText(c, x+swatch+fontScale, y, label(v), white)
+		y += lineSize
+	}
+}
+
+// distinct returns the values found in values, each once, in order
+func distinct(values []float64) []float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	var out []float64
+	for i, v := range sorted {
+		if i == 0 || v != sorted[i-1] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"cgsimu/engine"
+)
+
+// Outputs are how a run draws its gif, and the files it can write next to it
+type Outputs struct {
+	Render Render       // how the boards are drawn into the gif
+	Frames FrameOptions // how the frames are written: the gif, or another of FrameFormats
+
+	Metrics    bool   // filename.metrics.csv: one row of engine.Metrics per generation
+	Trajectory string // filename.trajectory.csv or .bin: every cell of every generation, in one of engine.TrajectoryFormats
+	Lineage    string // filename.lineage.nwk or .json: the lineage tree of every cell, in one of engine.LineageFormats
+	Graph      string // filename.graph.graphml or .csv and filename.degrees.csv: the neighbour graph of every generation, in one of engine.GraphFormats
+}
+
+// outputFiles are the open files of the Outputs of a run
+type outputFiles struct {
+	files      []*os.File
+	buffers    []*bufio.Writer
+	metrics    *engine.MetricsWriter
+	trajectory engine.TrajectoryWriter
+	lineage    *engine.Lineage
+	lineageOut *bufio.Writer
+	lineageFmt string
+	graph      engine.GraphWriter
+	degrees    *engine.DegreeWriter
+}
+
+// openOutputs creates the files of outs for a run of model with seed saved as filename, and exits if it cannot
+func openOutputs(outs Outputs, filename, model string, zones int, seed int64) *outputFiles {
+	o := new(outputFiles)
+	if outs.Metrics {
+		o.metrics = engine.NewMetricsWriter(o.create(filename+".metrics.csv"), model, zones, seed)
+	}
+	if outs.Trajectory != "" {
+		ext := ".trajectory.csv"
+		if outs.Trajectory == "binary" {
+			ext = ".trajectory.bin"
+		}
+		t, err := engine.NewTrajectoryWriter(o.create(filename+ext), outs.Trajectory, seed)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		o.trajectory = t
+	}
+	if outs.Lineage != "" {
+		ext := ".lineage.json"
+		if outs.Lineage == "newick" {
+			ext = ".lineage.nwk"
+		}
+		o.lineage = engine.NewLineage(seed)
+		o.lineageOut = o.create(filename + ext)
+		o.lineageFmt = outs.Lineage
+	}
+	if outs.Graph != "" {
+		ext := ".graph.csv"
+		if outs.Graph == "graphml" {
+			ext = ".graph.graphml"
+		}
+		g, err := engine.NewGraphWriter(o.create(filename+ext), outs.Graph, seed)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		o.graph = g
+		o.degrees = engine.NewDegreeWriter(o.create(filename+".degrees.csv"), seed)
+	}
+	return o
+}
+
+// create creates filename, buffered, and exits if it cannot
+func (o *outputFiles) create(filename string) *bufio.Writer {
+	file, err := os.Create(filename)
+	if err != nil {
+		fmt.Println("Sorry: couldn't create the file!", err)
+		os.Exit(1)
+	}
+	w := bufio.NewWriter(file)
+	o.files = append(o.files, file)
+	o.buffers = append(o.buffers, w)
+	return w
+}
+
+// writeGraph writes g and its degree distribution
+func (o *outputFiles) writeGraph(g engine.NeighbourGraph) error {
+	if err := o.graph.Write(g); err != nil {
+		return err
+	}
+	return o.degrees.Write(g)
+}
+
+// saveCheckpoint saves c to filename.checkpoint.json, with the lineage recorded so far if the run writes one
+func (o *outputFiles) saveCheckpoint(c engine.Checkpoint, filename string) error {
+	if o.lineage != nil {
+		c.Lineage = o.lineage.Nodes()
+	}
+	return engine.SaveCheckpoint(c, filename+".checkpoint.json")
+}
+
+// close flushes and closes every file, and returns err, or else the first error met doing so
+func (o *outputFiles) close(err error) error {
+	keep := func(e error) {
+		if err == nil {
+			err = e
+		}
+	}
+	if o.metrics != nil {
+		keep(o.metrics.Flush())
+	}
+	if o.trajectory != nil {
+		keep(o.trajectory.Flush())
+	}
+	if o.graph != nil {
+		keep(o.graph.Close())
+		keep(o.degrees.Flush())
+	}
+	// the lineage is only known at the end, and is written even if the run failed
+	if o.lineage != nil {
+		keep(o.lineage.Write(o.lineageOut, o.lineageFmt))
+	}
+	for i := range o.files {
+		keep(o.buffers[i].Flush())
+		keep(o.files[i].Close())
+	}
+	return err
+}
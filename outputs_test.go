@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"cgsimu/engine"
+)
+
+// runAll runs model with every output in format into a directory of its own, and returns the files it wrote by name
+func runAll(t *testing.T, model string, format, trajectory, lineage, graph string) map[string][]byte {
+	t.Helper()
+	cfg := engine.DefaultConfig()
+	cfg.InitialCells = 30
+	cfg.NumGens = 6
+	cfg.Seed = 42
+	outs := Outputs{Metrics: true, Trajectory: trajectory, Lineage: lineage, Graph: graph, Frames: DefaultFrameOptions()}
+	outs.Frames.Format = format
+	outs.Render.Radius, outs.Render.Scale = 1, 0.2
+
+	dir := t.TempDir()
+	if model == engine.ModelTwoCluster {
+		RunTwoCluster(cfg, filepath.Join(dir, "run"), outs)
+	} else {
+		RunOneCluster(cfg, filepath.Join(dir, "run"), outs)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string][]byte)
+	for _, e := range entries {
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[e.Name()] = data
+	}
+	return files
+}
+
+/*
+	TestSameSeedSameOutputs runs each model twice with the same seed and config, and
+	checks that every file is the same to the byte, the boards in the trajectory
+	included, and that every file records the seed.
+*/
+
+func TestSameSeedSameOutputs(t *testing.T) {
+	for _, tc := range []struct {
+		model, format, trajectory, lineage, graph string
+	}{
+		{engine.ModelOneCluster, "gif", "csv", "newick", "graphml"},
+		{engine.ModelOneCluster, "png", "binary", "json", "edgelist"},
+		{engine.ModelTwoCluster, "apng", "csv", "json", "graphml"},
+		{engine.ModelTwoCluster, "avi", "binary", "newick", "edgelist"},
+	} {
+		t.Run(tc.model+" "+tc.format, func(t *testing.T) {
+			first := runAll(t, tc.model, tc.format, tc.trajectory, tc.lineage, tc.graph)
+			second := runAll(t, tc.model, tc.format, tc.trajectory, tc.lineage, tc.graph)
+			if len(first) != len(second) {
+				t.Fatalf("the runs wrote %d and %d files", len(first), len(second))
+			}
+			for name, data := range first {
+				if !bytes.Equal(data, second[name]) {
+					t.Errorf("%s differs between two runs of the same seed", name)
+				}
+				if !recordsSeed(t, name, data, 42) {
+					t.Errorf("%s does not record the seed", name)
+				}
+			}
+		})
+	}
+}
+
+// recordsSeed reports whether the file name holds seed where its format keeps it
+func recordsSeed(t *testing.T, name string, data []byte, seed int64) bool {
+	t.Helper()
+	comment := engine.SeedComment(seed)
+	switch {
+	case strings.HasSuffix(name, ".trajectory.bin"):
+		trajectory, err := engine.ReadTrajectory(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return trajectory.Seed == seed
+	case strings.HasSuffix(name, ".csv"):
+		return strings.HasPrefix(string(data), "# "+comment+"\n")
+	case strings.HasSuffix(name, ".nwk"):
+		return strings.HasPrefix(string(data), "["+comment+"]")
+	case strings.HasSuffix(name, ".json"):
+		var out struct{ Seed int64 }
+		if err := json.Unmarshal(data, &out); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		return out.Seed == seed
+	case strings.HasSuffix(name, ".graphml"):
+		return bytes.Contains(data, []byte(`<data key="seed">42</data>`))
+	case strings.HasSuffix(name, ".gif"):
+		if _, err := gif.DecodeAll(bytes.NewReader(data)); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		return bytes.Contains(data, append([]byte{0x21, 0xfe, byte(len(comment))}, comment...))
+	case strings.HasSuffix(name, ".png"):
+		if _, err := png.Decode(bytes.NewReader(data)); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		return bytes.Contains(data, []byte("tEXtComment\x00"+comment))
+	case strings.HasSuffix(name, ".avi"):
+		return bytes.Contains(data, []byte("INFOICMT")) && bytes.Contains(data, []byte(comment+"\x00"))
+	}
+	t.Fatalf("no check of the seed of %s", name)
+	return false
+}